@@ -0,0 +1,352 @@
+package deck_acl
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"strings"
+	"tui-deck/deck_db"
+	"tui-deck/deck_help"
+	"tui-deck/deck_http"
+	"tui-deck/deck_structs"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+var AclList *tview.List
+var SearchFlex *tview.Flex
+var SearchInput *tview.InputField
+var ResultList *tview.List
+var Modal *tview.Modal
+var app *tview.Application
+var configuration utils.Configuration
+
+// Back goes back to the board list, changed reports if the sharing of the board was changed
+var Back func(boardId int, changed bool)
+
+var currentBoard deck_structs.Board
+var sharees []deck_structs.Sharee
+var changed = false
+
+func Init(application *tview.Application, conf utils.Configuration) {
+	app = application
+	configuration = conf
+
+	AclList = tview.NewList()
+	AclList.SetBorder(true)
+	AclList.SetBorderColor(utils.GetColor(configuration.Color))
+	AclList.SetSelectedBackgroundColor(utils.GetColor(configuration.Color))
+	Modal = tview.NewModal()
+
+	AclList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			// ESC -> back to board list
+			Back(currentBoard.Id, changed)
+			return nil
+		} else if event.Rune() == 97 {
+			// a -> add share
+			if canShare("share the board") {
+				buildSearch()
+			}
+			return nil
+		} else if event.Rune() == 101 || event.Rune() == 115 || event.Rune() == 109 {
+			// e, s, m -> toggle edit, share, manage permission
+			acl, ok := selectedAcl()
+			if !ok || !canShare("change the sharing") {
+				return nil
+			}
+			switch event.Rune() {
+			case 101:
+				acl.PermissionEdit = !acl.PermissionEdit
+			case 115:
+				acl.PermissionShare = !acl.PermissionShare
+			case 109:
+				if !currentBoard.CanManage() {
+					deck_ui.FooterBar.SetText("Can't grant manage permission: you are not allowed to manage this board")
+					return nil
+				}
+				acl.PermissionManage = !acl.PermissionManage
+			}
+			updateAcl(acl)
+			return nil
+		} else if event.Rune() == 100 {
+			// d -> remove share
+			acl, ok := selectedAcl()
+			if !ok || !canShare("remove the sharing") {
+				return nil
+			}
+			deleteAcl(acl)
+			return nil
+		} else if event.Rune() == 63 {
+			// ? -> help
+			deck_ui.BuildHelp(AclList, deck_help.HelpAcl)
+			return nil
+		}
+		return event
+	})
+
+	SearchInput = tview.NewInputField()
+	SearchInput.SetLabel("Search: ")
+	SearchInput.SetBorder(true)
+	SearchInput.SetBorderColor(utils.GetColor(configuration.Color))
+	SearchInput.SetFieldBackgroundColor(tcell.ColorWhite)
+	SearchInput.SetFieldTextColor(tcell.ColorBlack)
+	SearchInput.SetLabelColor(utils.GetColor(configuration.Color))
+	SearchInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			search(SearchInput.GetText())
+		} else if key == tcell.KeyTab {
+			app.SetFocus(ResultList)
+		}
+	})
+
+	ResultList = tview.NewList()
+	ResultList.SetBorder(true)
+	ResultList.SetTitle(" users, groups and circles ")
+	ResultList.SetBorderColor(utils.GetColor(configuration.Color))
+	ResultList.SetSelectedBackgroundColor(utils.GetColor(configuration.Color))
+	ResultList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyTab {
+			app.SetFocus(SearchInput)
+			return nil
+		}
+		return event
+	})
+	ResultList.SetSelectedFunc(func(index int, name string, secondName string, shortcut rune) {
+		if index < len(sharees) {
+			addAcl(sharees[index])
+		}
+	})
+
+	SearchFlex = tview.NewFlex()
+	SearchFlex.SetDirection(tview.FlexRow)
+	SearchFlex.SetBorder(true)
+	SearchFlex.SetBorderColor(utils.GetColor(configuration.Color))
+	SearchFlex.AddItem(SearchInput, 3, 0, true)
+	SearchFlex.AddItem(ResultList, 0, 1, false)
+	SearchFlex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			// ESC -> back to the shares
+			deck_ui.BuildFullFlex(AclList, nil)
+			return nil
+		}
+		return event
+	})
+}
+
+// BuildAcl shows the users, groups and circles a board is shared with and their permissions
+func BuildAcl(board deck_structs.Board) {
+	currentBoard = board
+	changed = false
+	AclList.Clear()
+	AclList.SetTitle(fmt.Sprintf(" SHARING - [#%s]%s[-:-:-] ", board.Color, board.Title))
+	AclList.AddItem("[gray]Loading shares...[-]", "", rune(0), nil)
+	deck_ui.BuildFullFlex(AclList, nil)
+
+	go func() {
+		detail, err := deck_db.GetBoardDetails(board.Id, true, configuration)
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting board shares: %s", err.Error()))
+				AclList.Clear()
+				return
+			}
+			currentBoard = detail
+			render()
+		})
+	}()
+}
+
+func render() {
+	current := AclList.GetCurrentItem()
+	AclList.Clear()
+	AclList.AddItem(fmt.Sprintf("[%s]Owner[white] - %s", configuration.Color, tview.Escape(currentBoard.Owner.DisplayName)),
+		"[gray]all permissions[-]", rune(0), nil)
+	for _, acl := range currentBoard.Acl {
+		AclList.AddItem(fmt.Sprintf("[%s]#%d[white] - %s [gray](%s)[-]", configuration.Color, acl.Id,
+			tview.Escape(acl.Participant.DisplayName), deck_structs.ParticipantTypeName(acl.Type)), renderPermissions(acl), rune(0), nil)
+	}
+	if current >= AclList.GetItemCount() {
+		current = AclList.GetItemCount() - 1
+	}
+	AclList.SetCurrentItem(current)
+}
+
+func renderPermissions(acl deck_structs.Acl) string {
+	permissions := make([]string, 0)
+	for _, p := range []struct {
+		name    string
+		granted bool
+	}{{"edit", acl.PermissionEdit}, {"share", acl.PermissionShare}, {"manage", acl.PermissionManage}} {
+		if p.granted {
+			permissions = append(permissions, fmt.Sprintf("[green]✔ %s[-]", p.name))
+		} else {
+			permissions = append(permissions, fmt.Sprintf("[gray]✗ %s[-]", p.name))
+		}
+	}
+	return strings.Join(permissions, "  ")
+}
+
+// canShare reports if the sharing of the board can be changed, explaining in the footer why not
+func canShare(action string) bool {
+	if currentBoard.CanShare() {
+		return true
+	}
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Can't %s: you are not allowed to share this board", action))
+	return false
+}
+
+func selectedAcl() (deck_structs.Acl, bool) {
+	text, _ := AclList.GetItemText(AclList.GetCurrentItem())
+	aclId := utils.GetId(text)
+	for _, acl := range currentBoard.Acl {
+		if acl.Id == aclId {
+			return acl, true
+		}
+	}
+	return deck_structs.Acl{}, false
+}
+
+func buildSearch() {
+	sharees = make([]deck_structs.Sharee, 0)
+	SearchInput.SetText("")
+	ResultList.Clear()
+	SearchFlex.SetTitle(fmt.Sprintf(" SHARE [#%s]%s[-:-:-] WITH ", currentBoard.Color, currentBoard.Title))
+	deck_ui.BuildFullFlex(SearchFlex, nil)
+	deck_ui.FooterBar.SetText("Type a name and press [yellow]ENTER[white] to search, [yellow]TAB[white] to switch to the results")
+}
+
+func search(text string) {
+	if len(strings.TrimSpace(text)) == 0 {
+		return
+	}
+	ResultList.Clear()
+	ResultList.AddItem("[gray]Searching...[-]", "", rune(0), nil)
+
+	go func() {
+		result, err := deck_http.SearchSharees(text, configuration)
+		app.QueueUpdateDraw(func() {
+			ResultList.Clear()
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error searching users: %s", err.Error()))
+				return
+			}
+			sharees = make([]deck_structs.Sharee, 0)
+			for _, s := range result {
+				if shared(s) {
+					continue
+				}
+				sharees = append(sharees, s)
+				ResultList.AddItem(fmt.Sprintf("%s [gray](%s)[-]", tview.Escape(s.Label), deck_structs.ParticipantTypeName(s.Value.ShareType)),
+					"", rune(0), nil)
+			}
+			if len(sharees) == 0 {
+				ResultList.AddItem("[gray]No results[-]", "", rune(0), nil)
+				return
+			}
+			app.SetFocus(ResultList)
+		})
+	}()
+}
+
+// shared reports if the board is already shared with a sharee, or owned by it
+func shared(s deck_structs.Sharee) bool {
+	if s.Value.ShareType == deck_structs.ParticipantUser && s.Value.ShareWith == currentBoard.Owner.Uid {
+		return true
+	}
+	for _, acl := range currentBoard.Acl {
+		if acl.Type == s.Value.ShareType && acl.Participant.Uid == s.Value.ShareWith {
+			return true
+		}
+	}
+	return false
+}
+
+func addAcl(s deck_structs.Sharee) {
+	jsonBody := fmt.Sprintf(`{"type": %d, "participant": "%s", "permissionEdit": false, "permissionShare": false, "permissionManage": false}`,
+		s.Value.ShareType, utils.CleanText(s.Value.ShareWith))
+	deck_ui.BuildFullFlex(AclList, nil)
+
+	go func() {
+		acl, err := deck_http.AddAcl(currentBoard.Id, jsonBody, configuration)
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error sharing board: %s", err.Error()))
+				return
+			}
+			changed = true
+			currentBoard.Acl = append(currentBoard.Acl, acl)
+			render()
+			AclList.SetCurrentItem(AclList.GetItemCount() - 1)
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Board shared with %s, press [yellow]e[white], [yellow]s[white] or [yellow]m[white] to grant permissions", tview.Escape(s.Label)))
+		})
+	}()
+}
+
+func updateAcl(acl deck_structs.Acl) {
+	jsonBody := fmt.Sprintf(`{"permissionEdit": %t, "permissionShare": %t, "permissionManage": %t}`,
+		acl.PermissionEdit, acl.PermissionShare, acl.PermissionManage)
+
+	go func() {
+		updated, err := deck_http.UpdateAcl(currentBoard.Id, acl.Id, jsonBody, configuration)
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error changing permissions: %s", err.Error()))
+				return
+			}
+			changed = true
+			for i, a := range currentBoard.Acl {
+				if a.Id == updated.Id {
+					currentBoard.Acl[i] = updated
+					break
+				}
+			}
+			render()
+		})
+	}()
+}
+
+func deleteAcl(acl deck_structs.Acl) {
+	Modal.ClearButtons()
+	Modal.SetText(fmt.Sprintf("Are you sure to stop sharing the board with %s?", acl.Participant.DisplayName))
+	Modal.SetBackgroundColor(utils.GetColor(configuration.Color))
+	Modal.AddButtons([]string{"Yes", "No"})
+
+	Modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			deck_ui.FullFlex.RemoveItem(Modal)
+			app.SetFocus(AclList)
+		}
+		if event.Key() == tcell.KeyRight || event.Key() == tcell.KeyLeft || event.Key() == tcell.KeyEnter {
+			return event
+		}
+		return nil
+	})
+
+	Modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "Yes" {
+			go func() {
+				_, err := deck_http.DeleteAcl(currentBoard.Id, acl.Id, configuration)
+				app.QueueUpdateDraw(func() {
+					if err != nil {
+						deck_ui.FooterBar.SetText(fmt.Sprintf("Error removing share: %s", err.Error()))
+						return
+					}
+					changed = true
+					for i, a := range currentBoard.Acl {
+						if a.Id == acl.Id {
+							currentBoard.Acl = append(currentBoard.Acl[:i], currentBoard.Acl[i+1:]...)
+							break
+						}
+					}
+					render()
+				})
+			}()
+		}
+		deck_ui.FullFlex.RemoveItem(Modal)
+		app.SetFocus(AclList)
+	})
+
+	deck_ui.FullFlex.AddItem(Modal, 0, 0, false)
+	app.SetFocus(Modal)
+}
@@ -0,0 +1,150 @@
+package deck_activity
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rivo/tview"
+	"sort"
+	"strings"
+	"time"
+	"tui-deck/deck_date"
+	"tui-deck/deck_http"
+	"tui-deck/deck_markdown"
+	"tui-deck/deck_structs"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+// number of activities and comments fetched for the timeline
+const timelineSize = 100
+
+var ActivityView *tview.TextView
+var app *tview.Application
+var configuration utils.Configuration
+
+// card of the last requested activity, older requests finishing later are dropped
+var currentCardId int
+
+type timelineEntry struct {
+	Time  time.Time
+	Lines []string
+}
+
+func Init(application *tview.Application, conf utils.Configuration) {
+	app = application
+	configuration = conf
+
+	ActivityView = tview.NewTextView()
+	ActivityView.SetBorder(true)
+	ActivityView.SetBorderColor(utils.GetColor(configuration.Color))
+	ActivityView.SetDynamicColors(true)
+	ActivityView.SetWordWrap(true)
+}
+
+// LoadActivity fills the view with the activities of a card merged with its comments, newest first
+func LoadActivity(cardId int) {
+	currentCardId = cardId
+	ActivityView.SetText("[gray]Loading activity...[-]")
+
+	go func() {
+		activities, err := deck_http.GetActivities(cardId, timelineSize, configuration)
+		comments, commentsErr := deck_http.GetComments(cardId, timelineSize, 0, configuration)
+		app.QueueUpdateDraw(func() {
+			if cardId != currentCardId {
+				return
+			}
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting card activity (is the activity app enabled?): %s", err.Error()))
+			} else if commentsErr != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting comments from card: %s", commentsErr.Error()))
+			}
+			ActivityView.SetText(renderTimeline(activities, comments))
+			ActivityView.ScrollToBeginning()
+		})
+	}()
+}
+
+func renderTimeline(activities []deck_structs.Activity, comments []deck_structs.Comment) string {
+	entries := make([]timelineEntry, 0)
+	for _, a := range activities {
+		// comments are taken from the comments api, with replies and mentions
+		if a.Type == "deck_comment" {
+			continue
+		}
+		created, err := deck_date.FromApi(a.Datetime)
+		if err != nil {
+			continue
+		}
+		lines := []string{renderSubject(a)}
+		if len(a.Message) > 0 {
+			for _, l := range strings.Split(strings.TrimSpace(a.Message), "\n") {
+				lines = append(lines, fmt.Sprintf("  [gray]%s[-]", tview.Escape(l)))
+			}
+		}
+		entries = append(entries, timelineEntry{Time: created, Lines: lines})
+	}
+	for _, c := range comments {
+		created, err := deck_date.FromApi(c.CreationDateTime)
+		if err != nil {
+			continue
+		}
+		action := "commented"
+		if c.ReplyTo != nil {
+			action = fmt.Sprintf("replied to %s", tview.Escape(c.ReplyTo.ActorDisplayName))
+		}
+		lines := []string{fmt.Sprintf("[%s::b]%s[-::-] %s:", configuration.Color, tview.Escape(c.ActorDisplayName), action)}
+		message := deck_markdown.GetMarkDownComment(c.Message, c.Mentions, configuration, false, 0)
+		for _, l := range strings.Split(strings.TrimRight(message, "\n"), "\n") {
+			lines = append(lines, "  │ "+l)
+		}
+		entries = append(entries, timelineEntry{Time: created, Lines: lines})
+	}
+
+	if len(entries) == 0 {
+		return "[gray]No activity for this card[-]"
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+
+	now := time.Now()
+	lines := make([]string, 0)
+	day := ""
+	for _, e := range entries {
+		// a separator for each day
+		if d := e.Time.Local().Format("Mon 02 Jan 2006"); d != day {
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, fmt.Sprintf("[%s::u]%s[-::-]", configuration.Color, d))
+			day = d
+		}
+		lines = append(lines, fmt.Sprintf("[gray]%s · %s[-] %s", e.Time.Local().Format("15:04"), deck_date.Ago(e.Time, now), e.Lines[0]))
+		for _, l := range e.Lines[1:] {
+			lines = append(lines, "      "+l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderSubject replaces the {placeholders} of the rich subject with the names of their objects
+func renderSubject(a deck_structs.Activity) string {
+	var subject string
+	if len(a.SubjectRich) == 0 || json.Unmarshal(a.SubjectRich[0], &subject) != nil || len(subject) == 0 {
+		return tview.Escape(a.Subject)
+	}
+	// php encodes an empty parameters map as []
+	parameters := make(map[string]deck_structs.RichObject)
+	if len(a.SubjectRich) > 1 {
+		_ = json.Unmarshal(a.SubjectRich[1], &parameters)
+	}
+	subject = tview.Escape(subject)
+	for key, object := range parameters {
+		color := "white"
+		if object.Type == "user" {
+			color = configuration.Color
+		}
+		subject = strings.ReplaceAll(subject, fmt.Sprintf("{%s}", key), fmt.Sprintf("[%s::b]%s[-::-]", color, tview.Escape(object.Name)))
+	}
+	return subject
+}
@@ -0,0 +1,173 @@
+package deck_archive
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"time"
+	"tui-deck/deck_card"
+	"tui-deck/deck_date"
+	"tui-deck/deck_help"
+	"tui-deck/deck_http"
+	"tui-deck/deck_structs"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+var ArchiveList *tview.List
+var Modal *tview.Modal
+var app *tview.Application
+var configuration utils.Configuration
+
+// archived cards in list order, with the title of their stack
+var archivedCards []deck_structs.Card
+var stackTitles map[int]string
+var currentBoard deck_structs.Board
+
+func Init(application *tview.Application, conf utils.Configuration) {
+	app = application
+	configuration = conf
+
+	ArchiveList = tview.NewList()
+	ArchiveList.SetBorder(true)
+	ArchiveList.SetBorderColor(utils.GetColor(configuration.Color))
+	ArchiveList.SetSelectedBackgroundColor(utils.GetColor(configuration.Color))
+	Modal = tview.NewModal()
+
+	ArchiveList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			// ESC -> back to main view
+			deck_ui.BuildFullFlex(deck_ui.MainFlex, nil)
+			return nil
+		} else if event.Rune() == 120 || event.Rune() == 117 {
+			// x, u -> unarchive card
+			card, ok := selectedCard()
+			if !ok || !deck_card.CanEdit("unarchive cards") {
+				return nil
+			}
+			deck_card.ArchiveCard(card, false, func() {
+				removeCard(card.Id)
+			})
+			app.SetFocus(ArchiveList)
+			return nil
+		} else if event.Rune() == 100 {
+			// d -> delete card
+			card, ok := selectedCard()
+			if !ok || !deck_card.CanEdit("delete cards") {
+				return nil
+			}
+			deleteCard(card)
+			return nil
+		} else if event.Rune() == 63 {
+			// ? -> help
+			deck_ui.BuildHelp(ArchiveList, deck_help.HelpArchive)
+			return nil
+		}
+		return event
+	})
+	ArchiveList.SetSelectedFunc(func(index int, name string, secondName string, shortcut rune) {
+		if index < len(archivedCards) {
+			deck_card.ShowCard(archivedCards[index])
+		}
+	})
+}
+
+// BuildArchive shows the archived cards of a board, fetched from its archived stacks
+func BuildArchive(board deck_structs.Board) {
+	currentBoard = board
+	archivedCards = make([]deck_structs.Card, 0)
+	ArchiveList.Clear()
+	ArchiveList.SetTitle(fmt.Sprintf(" ARCHIVED CARDS - %s ", board.Title))
+	ArchiveList.AddItem("[gray]Loading archived cards...[-]", "", rune(0), nil)
+	deck_ui.BuildFullFlex(ArchiveList, nil)
+
+	go func() {
+		stacks, err := deck_http.GetArchivedStacks(board.Id, configuration)
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting archived cards: %s", err.Error()))
+			}
+			stackTitles = make(map[int]string)
+			for _, s := range stacks {
+				stackTitles[s.Id] = s.Title
+				archivedCards = append(archivedCards, s.Cards...)
+			}
+			render()
+		})
+	}()
+}
+
+func render() {
+	ArchiveList.Clear()
+	if len(archivedCards) == 0 {
+		ArchiveList.AddItem("[gray]No archived cards[-]", "", rune(0), nil)
+		return
+	}
+	now := time.Now()
+	for _, c := range archivedCards {
+		secondary := fmt.Sprintf("in %s", stackTitles[c.StackId])
+		if c.LastModified > 0 {
+			secondary = fmt.Sprintf("%s · archived %s", secondary, deck_date.Ago(time.Unix(c.LastModified, 0), now))
+		}
+		ArchiveList.AddItem(fmt.Sprintf("[%s]#%d[white] - %s", configuration.Color, c.Id, tview.Escape(c.Title)),
+			secondary, rune(0), nil)
+	}
+}
+
+func selectedCard() (deck_structs.Card, bool) {
+	index := ArchiveList.GetCurrentItem()
+	if index < 0 || index >= len(archivedCards) {
+		return deck_structs.Card{}, false
+	}
+	return archivedCards[index], true
+}
+
+func removeCard(cardId int) {
+	for i, c := range archivedCards {
+		if c.Id == cardId {
+			archivedCards = append(archivedCards[:i], archivedCards[i+1:]...)
+			break
+		}
+	}
+	current := ArchiveList.GetCurrentItem()
+	render()
+	if current >= ArchiveList.GetItemCount() {
+		current = ArchiveList.GetItemCount() - 1
+	}
+	ArchiveList.SetCurrentItem(current)
+}
+
+func deleteCard(card deck_structs.Card) {
+	Modal.ClearButtons()
+	Modal.SetText(fmt.Sprintf("Are you sure to delete card #%d?", card.Id))
+	Modal.SetBackgroundColor(utils.GetColor(configuration.Color))
+	Modal.AddButtons([]string{"Yes", "No"})
+
+	Modal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			deck_ui.FullFlex.RemoveItem(Modal)
+			app.SetFocus(ArchiveList)
+		}
+		if event.Key() == tcell.KeyRight || event.Key() == tcell.KeyLeft || event.Key() == tcell.KeyEnter {
+			return event
+		}
+		return nil
+	})
+
+	Modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "Yes" {
+			go func() {
+				_, err := deck_http.DeleteCard(currentBoard.Id, card.StackId, card.Id, configuration)
+				if err != nil {
+					deck_ui.FooterBar.SetText(fmt.Sprintf("Error deleting card: %s", err.Error()))
+				}
+			}()
+			removeCard(card.Id)
+		}
+		deck_ui.FullFlex.RemoveItem(Modal)
+		app.SetFocus(ArchiveList)
+	})
+
+	deck_ui.FullFlex.AddItem(Modal, 0, 0, false)
+	app.SetFocus(Modal)
+}
@@ -0,0 +1,95 @@
+package deck_attachment
+
+import (
+	"fmt"
+	"github.com/rivo/tview"
+	"strings"
+	"time"
+	"tui-deck/deck_date"
+	"tui-deck/deck_http"
+	"tui-deck/deck_structs"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+var AttachmentView *tview.TextView
+var app *tview.Application
+var configuration utils.Configuration
+
+// card of the last requested attachments, older requests finishing later are dropped
+var currentCardId int
+
+func Init(application *tview.Application, conf utils.Configuration) {
+	app = application
+	configuration = conf
+
+	AttachmentView = tview.NewTextView()
+	AttachmentView.SetBorder(true)
+	AttachmentView.SetBorderColor(utils.GetColor(configuration.Color))
+	AttachmentView.SetDynamicColors(true)
+	AttachmentView.SetWordWrap(true)
+}
+
+// LoadAttachments fills the view with the files attached to a card
+func LoadAttachments(boardId int, stackId int, cardId int) {
+	currentCardId = cardId
+	AttachmentView.SetText("[gray]Loading attachments...[-]")
+
+	go func() {
+		attachments, err := deck_http.GetAttachments(boardId, stackId, cardId, configuration)
+		app.QueueUpdateDraw(func() {
+			if cardId != currentCardId {
+				return
+			}
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting attachments: %s", err.Error()))
+				AttachmentView.SetText("")
+				return
+			}
+			AttachmentView.SetText(renderAttachments(attachments))
+			AttachmentView.ScrollToBeginning()
+		})
+	}()
+}
+
+func renderAttachments(attachments []deck_structs.Attachment) string {
+	if len(attachments) == 0 {
+		return "[gray]No attachments for this card[-]"
+	}
+	now := time.Now()
+	lines := make([]string, 0)
+	for _, a := range attachments {
+		lines = append(lines, fmt.Sprintf("[%s::b]%s[-::-]", configuration.Color, tview.Escape(a.Data)))
+		details := make([]string, 0)
+		if len(a.ExtendedData.Mimetype) > 0 {
+			details = append(details, a.ExtendedData.Mimetype)
+		}
+		if a.ExtendedData.Filesize > 0 {
+			details = append(details, formatSize(a.ExtendedData.Filesize))
+		}
+		if len(a.CreatedBy) > 0 {
+			details = append(details, fmt.Sprintf("by %s", tview.Escape(a.CreatedBy)))
+		}
+		if a.CreatedAt > 0 {
+			created := time.Unix(a.CreatedAt, 0)
+			details = append(details, fmt.Sprintf("%s (%s)", deck_date.Format(created), deck_date.Ago(created, now)))
+		}
+		lines = append(lines, fmt.Sprintf("  [gray]%s[-]", strings.Join(details, " · ")), "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSize returns a size in bytes as a short text like "1.2 MB"
+func formatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
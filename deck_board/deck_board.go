@@ -1,10 +1,14 @@
 package deck_board
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"time"
+	"tui-deck/deck_acl"
 	"tui-deck/deck_card"
+	"tui-deck/deck_date"
 	"tui-deck/deck_db"
 	"tui-deck/deck_help"
 	"tui-deck/deck_http"
@@ -16,6 +20,7 @@ import (
 
 var BoardFlex *tview.Flex
 var BoardList *tview.List
+var TrashList *tview.List
 var EditTagsFlex *tview.Flex
 var modal = tview.NewModal()
 
@@ -35,15 +40,57 @@ func Init(application *tview.Application, conf utils.Configuration) {
 
 	BoardFlex.Clear()
 	BoardFlex.AddItem(BoardList, 0, 1, true)
+
+	deck_acl.Back = func(boardId int, changed bool) {
+		if changed {
+			// the users of the board change with its sharing
+			for i, b := range Boards {
+				if b.Id == boardId {
+					Boards[i].Updated = true
+					break
+				}
+			}
+			if CurrentBoard.Id == boardId {
+				if board, err := deck_db.GetBoardDetails(boardId, true, configuration); err == nil {
+					CurrentBoard = board
+					deck_card.SetCurrentBoard(CurrentBoard)
+				}
+			}
+		}
+		deck_ui.BuildFullFlex(BoardFlex, nil)
+	}
+
+	TrashList = tview.NewList()
+	TrashList.SetBorder(true)
+	TrashList.SetBorderColor(utils.GetColor(configuration.Color))
+	TrashList.SetSelectedBackgroundColor(utils.GetColor(configuration.Color))
+	TrashList.SetTitle(" TRASH - deleted boards ")
+	TrashList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			deck_ui.BuildFullFlex(BoardFlex, nil)
+			return nil
+		} else if event.Rune() == 114 {
+			// r -> restore board
+			text, _ := TrashList.GetItemText(TrashList.GetCurrentItem())
+			restoreBoard(utils.GetId(text))
+			return nil
+		} else if event.Rune() == 63 {
+			// ? deck_help menu
+			deck_ui.BuildHelp(TrashList, deck_help.HelpBoards)
+			return nil
+		}
+		return event
+	})
+	TrashList.SetSelectedFunc(func(index int, name string, secondName string, shortcut rune) {
+		restoreBoard(utils.GetId(name))
+	})
 }
 
 func BuildSwitchBoard(configuration utils.Configuration) {
 	BoardList.SetBorder(true)
 	BoardList.SetBorderColor(utils.GetColor(configuration.Color))
 	BoardList.SetTitle("Select Boards")
-	for _, b := range Boards {
-		BoardList.AddItem(fmt.Sprintf("[#%s]#%d - %s", b.Color, b.Id, b.Title), "", rune(0), nil)
-	}
+	renderBoards()
 	BoardList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
 			deck_ui.BuildFullFlex(deck_ui.MainFlex, nil)
@@ -60,13 +107,9 @@ func BuildSwitchBoard(configuration utils.Configuration) {
 			selectedBoardIndex := BoardList.GetCurrentItem()
 			text, _ := BoardList.GetItemText(selectedBoardIndex)
 
-			boardId := utils.GetId(text)
-			board := deck_structs.Board{}
-			for _, b := range Boards {
-				if b.Id == boardId {
-					board = b
-					break
-				}
+			board := findBoard(utils.GetId(text))
+			if board.Id == 0 || !canManage(board, "edit the board") {
+				return nil
 			}
 
 			editForm, editedBoard := buildAddBoardForm(board)
@@ -75,13 +118,8 @@ func BuildSwitchBoard(configuration utils.Configuration) {
 				go func() {
 					err = editBoard(*editedBoard)
 				}()
-				BoardList.SetItemText(selectedBoardIndex, fmt.Sprintf("[#%s]#%d - %s", editedBoard.Color, editedBoard.Id, editedBoard.Title), "")
-				for i, b := range Boards {
-					if b.Id == editedBoard.Id {
-						Boards[i] = *editedBoard
-						break
-					}
-				}
+				BoardList.SetItemText(selectedBoardIndex, buildBoardItem(*editedBoard), "")
+				updateBoard(*editedBoard)
 				deck_ui.BuildFullFlex(BoardFlex, err)
 			})
 			deck_ui.BuildFullFlex(editForm, nil)
@@ -90,6 +128,9 @@ func BuildSwitchBoard(configuration utils.Configuration) {
 			selectedBoardIndex := BoardList.GetCurrentItem()
 			text, _ := BoardList.GetItemText(selectedBoardIndex)
 			boardId := utils.GetId(text)
+			if boardId == 0 || !canManage(findBoard(boardId), "delete the board") {
+				return nil
+			}
 			modal = tview.NewModal()
 			modal.ClearButtons()
 			modal.SetText(fmt.Sprintf("Are you sure to delete bord #%d?", boardId))
@@ -115,7 +156,15 @@ func BuildSwitchBoard(configuration utils.Configuration) {
 							deck_ui.FooterBar.SetText(fmt.Sprintf("Error deleteing board: %s", err.Error()))
 						}
 					}()
-					BoardList.RemoveItem(selectedBoardIndex)
+					for i, b := range Boards {
+						if b.Id == boardId {
+							Boards = append(Boards[:i], Boards[i+1:]...)
+							break
+						}
+					}
+					renderBoards()
+					BoardList.SetCurrentItem(selectedBoardIndex)
+					deck_ui.FooterBar.SetText(fmt.Sprintf("Board #%d deleted, press [yellow]T[white] in the board list to restore it", boardId))
 					BoardFlex.RemoveItem(modal)
 					app.SetFocus(BoardList)
 				} else if buttonLabel == "No" {
@@ -133,8 +182,14 @@ func BuildSwitchBoard(configuration utils.Configuration) {
 			text, _ := BoardList.GetItemText(currentIndex)
 
 			boardId := utils.GetId(text)
+			if boardId == 0 {
+				return nil
+			}
 
-			board, _ := deck_db.GetBoardDetails(boardId, Boards[currentIndex].Updated, configuration)
+			board, _ := deck_db.GetBoardDetails(boardId, findBoard(boardId).Updated, configuration)
+			if !canManage(board, "edit the board labels") {
+				return nil
+			}
 
 			EditTagsFlex.Clear()
 			actualLabelList := tview.NewList()
@@ -231,6 +286,49 @@ func BuildSwitchBoard(configuration utils.Configuration) {
 			})
 			deck_ui.BuildFullFlex(EditTagsFlex, nil)
 
+		} else if event.Rune() == 120 {
+			// x -> archive / unarchive board
+			text, _ := BoardList.GetItemText(BoardList.GetCurrentItem())
+			board := findBoard(utils.GetId(text))
+			if board.Id == 0 || !canManage(board, "archive the board") {
+				return nil
+			}
+			board.Archived = !board.Archived
+			go func() {
+				err := editBoard(board)
+				if err == nil {
+					return
+				}
+				app.QueueUpdateDraw(func() {
+					// the server kept the board as it was
+					board.Archived = !board.Archived
+					updateBoard(board)
+					renderBoards()
+					focusBoard(board.Id)
+					deck_ui.FooterBar.SetText(fmt.Sprintf("Error archiving board: %s", err.Error()))
+				})
+			}()
+			updateBoard(board)
+			renderBoards()
+			focusBoard(board.Id)
+			if board.Archived {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Board #%d archived", board.Id))
+			} else {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Board #%d unarchived", board.Id))
+			}
+			return nil
+		} else if event.Rune() == 83 {
+			// S -> sharing
+			text, _ := BoardList.GetItemText(BoardList.GetCurrentItem())
+			board := findBoard(utils.GetId(text))
+			if board.Id != 0 {
+				deck_acl.BuildAcl(board)
+			}
+			return nil
+		} else if event.Rune() == 84 {
+			// T -> trash
+			buildTrash()
+			return nil
 		} else if event.Rune() == 63 {
 			// ? deck_help menu
 			deck_ui.BuildHelp(BoardList, deck_help.HelpBoards)
@@ -238,23 +336,183 @@ func BuildSwitchBoard(configuration utils.Configuration) {
 		return event
 	})
 	BoardList.SetSelectedFunc(func(index int, name string, secondName string, shortcut rune) {
-		var err error
-		CurrentBoard, err = deck_db.GetBoardDetails(Boards[index].Id, Boards[index].Updated, configuration)
-		Boards[index] = CurrentBoard
-		deck_card.SetCurrentBoard(CurrentBoard)
-		if err != nil {
-			deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting board detail: %s", err.Error()))
+		boardId := utils.GetId(name)
+		if boardId == 0 {
+			return
+		}
+		err := SelectBoard(boardId)
+		deck_ui.BuildFullFlex(deck_ui.MainFlex, err)
+	})
+}
+
+// renderBoards fills the board list, archived boards in a separate section at the bottom
+func renderBoards() {
+	BoardList.Clear()
+	archived := make([]deck_structs.Board, 0)
+	for _, b := range Boards {
+		if b.Archived {
+			archived = append(archived, b)
+			continue
+		}
+		BoardList.AddItem(buildBoardItem(b), "", rune(0), nil)
+	}
+	if len(archived) == 0 {
+		return
+	}
+	BoardList.AddItem("[gray]── archived boards ──[-]", "", rune(0), nil)
+	for _, b := range archived {
+		BoardList.AddItem(buildBoardItem(b), "", rune(0), nil)
+	}
+}
 
+func focusBoard(boardId int) {
+	for i := 0; i < BoardList.GetItemCount(); i++ {
+		if text, _ := BoardList.GetItemText(i); utils.GetId(text) == boardId {
+			BoardList.SetCurrentItem(i)
+			return
 		}
-		deck_ui.MainFlex.SetTitle(fmt.Sprintf(" TUI DECK: [#%s]%s ", CurrentBoard.Color, CurrentBoard.Title))
+	}
+}
 
-		deck_stack.Stacks, err = deck_db.GetStacks(CurrentBoard.Id, Boards[index].Updated, configuration)
-		if err != nil {
-			deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting stacks: %s", err.Error()))
+// updateBoard replaces a board in the list of boards, and the current board if it's the same
+func updateBoard(board deck_structs.Board) {
+	for i, b := range Boards {
+		if b.Id == board.Id {
+			Boards[i] = board
+			break
 		}
-		deck_card.BuildStacks()
-		deck_ui.BuildFullFlex(deck_ui.MainFlex, err)
-	})
+	}
+	if CurrentBoard.Id == board.Id {
+		CurrentBoard.Title = board.Title
+		CurrentBoard.Color = board.Color
+		CurrentBoard.Archived = board.Archived
+		deck_ui.MainFlex.SetTitle(GetMainTitle(CurrentBoard))
+	}
+}
+
+// buildTrash shows the deleted boards that the server still keeps, so they can be restored
+func buildTrash() {
+	TrashList.Clear()
+	TrashList.AddItem("[gray]Loading deleted boards...[-]", "", rune(0), nil)
+	deck_ui.BuildFullFlex(TrashList, nil)
+
+	go func() {
+		boards, err := deck_http.GetDeletedBoards(configuration)
+		app.QueueUpdateDraw(func() {
+			TrashList.Clear()
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting deleted boards: %s", err.Error()))
+			}
+			if len(boards) == 0 {
+				TrashList.AddItem("[gray]Trash is empty[-]", "", rune(0), nil)
+				return
+			}
+			now := time.Now()
+			for _, b := range boards {
+				TrashList.AddItem(fmt.Sprintf("[#%s]#%d - %s", b.Color, b.Id, b.Title),
+					fmt.Sprintf("deleted %s", deck_date.Ago(time.Unix(int64(b.DeletedAt), 0), now)), rune(0), nil)
+			}
+		})
+	}()
+}
+
+func restoreBoard(boardId int) {
+	if boardId == 0 {
+		return
+	}
+	go func() {
+		board, err := deck_http.UndoDeleteBoard(boardId, configuration)
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error restoring board: %s", err.Error()))
+				return
+			}
+			board.Updated = true
+			Boards = append(Boards, board)
+			renderBoards()
+			for i := 0; i < TrashList.GetItemCount(); i++ {
+				if text, _ := TrashList.GetItemText(i); utils.GetId(text) == boardId {
+					TrashList.RemoveItem(i)
+					break
+				}
+			}
+			if TrashList.GetItemCount() == 0 {
+				TrashList.AddItem("[gray]Trash is empty[-]", "", rune(0), nil)
+			}
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Board #%d restored", boardId))
+		})
+	}()
+}
+
+// buildBoardItem returns the text of a board in the board list, with its archived state and last change
+func buildBoardItem(board deck_structs.Board) string {
+	text := fmt.Sprintf("[#%s]#%d - %s", board.Color, board.Id, board.Title)
+	if board.Archived {
+		text = fmt.Sprintf("%s [black:gray] ARCHIVED [-:-:-]", text)
+	}
+	if !board.CanEdit() {
+		text = fmt.Sprintf("%s [black:gray] READ-ONLY [-:-:-]", text)
+	}
+	if board.LastModified > 0 {
+		text = fmt.Sprintf("%s [gray](modified %s)[-]", text, deck_date.Ago(time.Unix(board.LastModified, 0), time.Now()))
+	}
+	return text
+}
+
+// canManage reports if a board can be changed, explaining in the footer why not
+func canManage(board deck_structs.Board, action string) bool {
+	if board.CanManage() {
+		return true
+	}
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Can't %s: you are not allowed to manage this board", action))
+	return false
+}
+
+func findBoard(boardId int) deck_structs.Board {
+	for _, b := range Boards {
+		if b.Id == boardId {
+			return b
+		}
+	}
+	return deck_structs.Board{}
+}
+
+// GetMainTitle returns the title of the main view for a board
+func GetMainTitle(board deck_structs.Board) string {
+	if !board.CanEdit() {
+		return fmt.Sprintf(" TUI DECK: [#%s]%s [gray](read-only)[-] ", board.Color, board.Title)
+	}
+	return fmt.Sprintf(" TUI DECK: [#%s]%s ", board.Color, board.Title)
+}
+
+func SelectBoard(boardId int) error {
+	index := -1
+	for i, b := range Boards {
+		if b.Id == boardId {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Board #%d not found", boardId))
+		return errors.New("not found")
+	}
+	var err error
+	CurrentBoard, err = deck_db.GetBoardDetails(Boards[index].Id, Boards[index].Updated, configuration)
+	Boards[index] = CurrentBoard
+	deck_card.SetCurrentBoard(CurrentBoard)
+	if err != nil {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting board detail: %s", err.Error()))
+
+	}
+	deck_ui.MainFlex.SetTitle(GetMainTitle(CurrentBoard))
+
+	deck_stack.Stacks, err = deck_db.GetStacks(CurrentBoard.Id, Boards[index].Updated, configuration)
+	if err != nil {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting stacks: %s", err.Error()))
+	}
+	deck_card.BuildStacks()
+	return err
 }
 
 func addBoard(board deck_structs.Board) {
@@ -266,7 +524,7 @@ func addBoard(board deck_structs.Board) {
 		deck_ui.FooterBar.SetText(fmt.Sprintf("Error crating new card: %s", err.Error()))
 	}
 	Boards = append(Boards, newBoard)
-	BoardList.AddItem(fmt.Sprintf("[#%s]#%d - %s", newBoard.Color, newBoard.Id, newBoard.Title), "", rune(0), nil)
+	BoardList.AddItem(buildBoardItem(newBoard), "", rune(0), nil)
 	if board.CreateDefaults {
 		var items []string = []string{"Todo", "Running", "Complete"}
 		for i, s := range items {
@@ -282,7 +540,7 @@ func addBoard(board deck_structs.Board) {
 }
 
 func editBoard(board deck_structs.Board) error {
-	jsonBody := fmt.Sprintf(`{"title":"%s", "color": "%s"}`, board.Title, board.Color)
+	jsonBody := fmt.Sprintf(`{"title":"%s", "color": "%s", "archived": %t}`, board.Title, board.Color, board.Archived)
 	var err error
 	_, err = deck_http.EditBoard(board.Id, jsonBody, configuration)
 	if err != nil {
@@ -0,0 +1,390 @@
+package deck_calendar
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"sort"
+	"time"
+	"tui-deck/deck_board"
+	"tui-deck/deck_card"
+	"tui-deck/deck_date"
+	"tui-deck/deck_db"
+	"tui-deck/deck_help"
+	"tui-deck/deck_stack"
+	"tui-deck/deck_structs"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+var CalendarFlex *tview.Flex
+var CalendarTable *tview.Table
+var DayList *tview.List
+
+var selectedDay time.Time
+var weekView = false
+var allBoards = false
+var movingCard *calendarCard
+
+var cardsByDay = make(map[string][]calendarCard)
+
+var app *tview.Application
+var configuration utils.Configuration
+
+type calendarCard struct {
+	Card  deck_structs.Card
+	Board deck_structs.Board
+	Due   time.Time
+}
+
+func Init(application *tview.Application, conf utils.Configuration) {
+	app = application
+	configuration = conf
+
+	CalendarFlex = tview.NewFlex()
+	CalendarTable = tview.NewTable()
+	DayList = tview.NewList()
+
+	CalendarTable.SetBorders(true)
+	CalendarTable.SetBordersColor(utils.GetColor(configuration.Color))
+	CalendarTable.SetSelectable(true, true)
+	CalendarTable.SetSelectedStyle(tcell.StyleDefault.Background(utils.GetColor(configuration.Color)).Foreground(tcell.ColorWhite))
+
+	DayList.SetBorder(true)
+	DayList.SetBorderColor(utils.GetColor(configuration.Color))
+
+	CalendarFlex.SetDirection(tview.FlexRow)
+	CalendarFlex.SetBorder(true)
+	CalendarFlex.SetBorderColor(utils.GetColor(configuration.Color))
+	CalendarFlex.AddItem(CalendarTable, 0, 3, true)
+	CalendarFlex.AddItem(DayList, 0, 1, false)
+
+	CalendarTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			if movingCard != nil {
+				// ESC -> cancel reschedule
+				movingCard = nil
+				render()
+				deck_ui.FooterBar.SetText("Reschedule cancelled")
+				return nil
+			}
+			// ESC -> back to main view
+			deck_ui.BuildFullFlex(deck_ui.MainFlex, nil)
+			return nil
+		case tcell.KeyLeft:
+			moveSelection(0, -1)
+			return nil
+		case tcell.KeyRight:
+			moveSelection(0, 1)
+			return nil
+		case tcell.KeyUp:
+			moveSelection(0, -7)
+			return nil
+		case tcell.KeyDown:
+			moveSelection(0, 7)
+			return nil
+		case tcell.KeyTab:
+			if DayList.GetItemCount() > 0 {
+				app.SetFocus(DayList)
+			}
+			return nil
+		case tcell.KeyEnter:
+			if movingCard != nil {
+				reschedule(*movingCard, selectedDay)
+				return nil
+			}
+			if DayList.GetItemCount() > 0 {
+				app.SetFocus(DayList)
+			}
+			return nil
+		}
+		if event.Rune() == 110 {
+			// n -> next month / week
+			if weekView {
+				moveSelection(0, 7)
+			} else {
+				moveSelection(1, 0)
+			}
+			return nil
+		} else if event.Rune() == 112 {
+			// p -> previous month / week
+			if weekView {
+				moveSelection(0, -7)
+			} else {
+				moveSelection(-1, 0)
+			}
+			return nil
+		} else if event.Rune() == 116 {
+			// t -> today
+			now := time.Now()
+			selectedDay = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+			render()
+			return nil
+		} else if event.Rune() == 119 {
+			// w -> toggle month / week view
+			weekView = !weekView
+			render()
+			return nil
+		} else if event.Rune() == 98 {
+			// b -> toggle current board / all boards
+			allBoards = !allBoards
+			loadCards()
+			render()
+			return nil
+		} else if event.Rune() == 109 && movingCard != nil {
+			// m -> drop card on selected day
+			reschedule(*movingCard, selectedDay)
+			return nil
+		} else if event.Rune() == 63 {
+			// ? -> help
+			deck_ui.BuildHelp(CalendarFlex, deck_help.HelpCalendar)
+			return nil
+		}
+		return event
+	})
+
+	DayList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyTab {
+			app.SetFocus(CalendarTable)
+			return nil
+		} else if event.Rune() == 109 {
+			// m -> pick card to reschedule
+			cards := cardsByDay[dayKey(selectedDay)]
+			index := DayList.GetCurrentItem()
+			if index < len(cards) {
+				c := cards[index]
+				if !c.Board.CanEdit() {
+					deck_ui.FooterBar.SetText(fmt.Sprintf("Can't reschedule card #%d: the board is shared with you read-only", c.Card.Id))
+					return nil
+				}
+				movingCard = &c
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Moving card #%d: select a day and press [yellow]ENTER[white], [yellow]ESC[white] to cancel", c.Card.Id))
+				render()
+				app.SetFocus(CalendarTable)
+			}
+			return nil
+		}
+		return event
+	})
+
+	DayList.SetSelectedFunc(func(index int, name string, secondName string, shortcut rune) {
+		cards := cardsByDay[dayKey(selectedDay)]
+		if index >= len(cards) {
+			return
+		}
+		openCard(cards[index])
+	})
+}
+
+func BuildCalendar() {
+	now := time.Now()
+	if selectedDay.IsZero() {
+		selectedDay = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+	movingCard = nil
+	loadCards()
+	render()
+	deck_ui.BuildFullFlex(CalendarFlex, nil)
+	app.SetFocus(CalendarTable)
+}
+
+func loadCards() {
+	cardsByDay = make(map[string][]calendarCard)
+	if !allBoards {
+		addCards(deck_board.CurrentBoard, deck_stack.Stacks)
+		return
+	}
+	for _, b := range deck_board.Boards {
+		if b.Id == deck_board.CurrentBoard.Id {
+			addCards(deck_board.CurrentBoard, deck_stack.Stacks)
+			continue
+		}
+		stacks, err := deck_db.GetStacks(b.Id, b.Updated, configuration)
+		if err != nil {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting stacks for board %s: %s", b.Title, err.Error()))
+			continue
+		}
+		addCards(b, stacks)
+	}
+}
+
+func addCards(board deck_structs.Board, stacks []deck_structs.Stack) {
+	for _, s := range stacks {
+		for _, c := range s.Cards {
+			if len(c.DueDate) == 0 {
+				continue
+			}
+			due, err := deck_date.FromApi(c.DueDate)
+			if err != nil {
+				continue
+			}
+			due = due.Local()
+			key := dayKey(due)
+			cardsByDay[key] = append(cardsByDay[key], calendarCard{Card: c, Board: board, Due: due})
+		}
+	}
+	for k := range cardsByDay {
+		sort.Slice(cardsByDay[k], func(i, j int) bool {
+			return cardsByDay[k][i].Due.Before(cardsByDay[k][j].Due)
+		})
+	}
+}
+
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func moveSelection(months int, days int) {
+	selectedDay = selectedDay.AddDate(0, months, days)
+	render()
+}
+
+// firstDay returns the monday starting the grid for the selected day
+func firstDay() time.Time {
+	start := selectedDay
+	if !weekView {
+		start = time.Date(selectedDay.Year(), selectedDay.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	offset := (int(start.Weekday()) + 6) % 7
+	return start.AddDate(0, 0, -offset)
+}
+
+func render() {
+	CalendarTable.Clear()
+	color := utils.GetColor(configuration.Color)
+	for i, name := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		CalendarTable.SetCell(0, i, tview.NewTableCell(name).
+			SetTextColor(color).
+			SetAlign(tview.AlignCenter).
+			SetExpansion(1).
+			SetSelectable(false))
+	}
+
+	today := dayKey(time.Now())
+	start := firstDay()
+	selectedRow, selectedColumn := 1, 0
+
+	if weekView {
+		for i := 0; i < 7; i++ {
+			day := start.AddDate(0, 0, i)
+			cards := cardsByDay[dayKey(day)]
+			CalendarTable.SetCell(1, i, dayCell(day, today, fmt.Sprintf("%d %s", day.Day(), day.Format("Jan"))))
+			for j, c := range cards {
+				CalendarTable.SetCell(j+2, i, tview.NewTableCell(fmt.Sprintf("%s %s", c.Due.Format("15:04"), c.Card.Title)).
+					SetMaxWidth(20).
+					SetExpansion(1).
+					SetSelectable(false))
+			}
+			if dayKey(day) == dayKey(selectedDay) {
+				selectedColumn = i
+			}
+		}
+		CalendarFlex.SetTitle(fmt.Sprintf(" CALENDAR: week %s - %s ", start.Format("02 Jan"), start.AddDate(0, 0, 6).Format("02 Jan 2006")))
+	} else {
+		end := time.Date(selectedDay.Year(), selectedDay.Month()+1, 1, 0, 0, 0, 0, time.Local)
+		row := 1
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 7) {
+			for i := 0; i < 7; i++ {
+				d := day.AddDate(0, 0, i)
+				text := fmt.Sprintf("%2d", d.Day())
+				if count := len(cardsByDay[dayKey(d)]); count > 0 {
+					text = fmt.Sprintf("%2d  ● %d", d.Day(), count)
+				}
+				cell := dayCell(d, today, text)
+				if d.Month() != selectedDay.Month() {
+					cell.SetTextColor(tcell.ColorGray)
+				}
+				CalendarTable.SetCell(row, i, cell)
+				if dayKey(d) == dayKey(selectedDay) {
+					selectedRow, selectedColumn = row, i
+				}
+			}
+			row++
+		}
+		CalendarFlex.SetTitle(fmt.Sprintf(" CALENDAR: %s ", selectedDay.Format("January 2006")))
+	}
+	if allBoards {
+		CalendarFlex.SetTitle(fmt.Sprintf("%s- all boards ", CalendarFlex.GetTitle()))
+	} else {
+		CalendarFlex.SetTitle(fmt.Sprintf("%s- [#%s]%s[-:-:-] ", CalendarFlex.GetTitle(), deck_board.CurrentBoard.Color, deck_board.CurrentBoard.Title))
+	}
+	CalendarTable.Select(selectedRow, selectedColumn)
+	buildDayList()
+}
+
+func dayCell(day time.Time, today string, text string) *tview.TableCell {
+	cell := tview.NewTableCell(text).SetExpansion(1).SetAlign(tview.AlignCenter)
+	if dayKey(day) == today {
+		cell.SetAttributes(tcell.AttrBold | tcell.AttrUnderline)
+	}
+	if movingCard != nil && dayKey(day) == dayKey(movingCard.Due) {
+		cell.SetTextColor(tcell.ColorYellow)
+	}
+	return cell
+}
+
+func buildDayList() {
+	DayList.Clear()
+	DayList.SetTitle(fmt.Sprintf(" %s ", selectedDay.Format("Monday 02 January 2006")))
+	for _, c := range cardsByDay[dayKey(selectedDay)] {
+		title := fmt.Sprintf("[%s]#%d[white] - %s %s", configuration.Color, c.Card.Id, c.Due.Format("15:04"), c.Card.Title)
+		if allBoards {
+			title = fmt.Sprintf("%s - [#%s]%s[white]", title, c.Board.Color, c.Board.Title)
+		}
+		DayList.AddItem(title, utils.BuildLabels(c.Card), rune(0), nil)
+	}
+}
+
+func openCard(c calendarCard) {
+	if c.Board.Id != deck_board.CurrentBoard.Id {
+		if err := deck_board.SelectBoard(c.Board.Id); err != nil {
+			return
+		}
+	}
+	card, ok := deck_card.CardsMap[c.Card.Id]
+	if !ok {
+		card = c.Card
+	}
+	deck_card.ShowCard(card)
+}
+
+// reschedule moves the card due date to day keeping its time of day
+func reschedule(c calendarCard, day time.Time) {
+	movingCard = nil
+	due := time.Date(day.Year(), day.Month(), day.Day(), c.Due.Hour(), c.Due.Minute(), 0, 0, time.Local)
+	card := c.Card
+	if local, ok := deck_card.CardsMap[card.Id]; ok && c.Board.Id == deck_board.CurrentBoard.Id {
+		// the loaded copy has the latest changes
+		card = local
+	}
+	card.DueDate = deck_date.ToApi(due)
+	deck_card.UpdateCard(c.Board.Id, card)
+
+	if c.Board.Id == deck_board.CurrentBoard.Id {
+		deck_card.UpdateLocalCard(card)
+		deck_card.BuildStacks()
+	} else {
+		for i, b := range deck_board.Boards {
+			if b.Id == c.Board.Id {
+				deck_board.Boards[i].Updated = true
+			}
+		}
+	}
+
+	oldKey := dayKey(c.Due)
+	for i, dc := range cardsByDay[oldKey] {
+		if dc.Card.Id == card.Id {
+			cardsByDay[oldKey] = append(cardsByDay[oldKey][:i], cardsByDay[oldKey][i+1:]...)
+			break
+		}
+	}
+	c.Card = card
+	c.Due = due
+	cardsByDay[dayKey(due)] = append(cardsByDay[dayKey(due)], c)
+
+	selectedDay = day
+	render()
+	app.SetFocus(CalendarTable)
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d rescheduled to %s", card.Id, deck_date.Format(due)))
+}
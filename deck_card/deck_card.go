@@ -6,11 +6,19 @@ import (
 	"github.com/rivo/tview"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"tui-deck/deck_activity"
+	"tui-deck/deck_attachment"
 	"tui-deck/deck_comment"
+	"tui-deck/deck_date"
+	"tui-deck/deck_db"
 	"tui-deck/deck_help"
 	"tui-deck/deck_http"
+	"tui-deck/deck_link"
 	"tui-deck/deck_markdown"
+	"tui-deck/deck_merge"
 	"tui-deck/deck_stack"
 	"tui-deck/deck_structs"
 	"tui-deck/deck_ui"
@@ -23,9 +31,54 @@ var EditTagsFlex *tview.Flex
 var EditUsersFlex *tview.Flex
 var Modal *tview.Modal
 
+// CardFlex is the card detail screen: metadata header, tab bar and the content of the current tab
+var CardFlex *tview.Flex
+var cardHeader *tview.TextView
+var cardTabs *tview.TextView
+
+const (
+	descriptionTab = iota
+	commentsTab
+	attachmentsTab
+	activityTab
+)
+
+var tabNames = []string{"Description", "Comments", "Attachments", "Activity"}
+var currentTab = descriptionTab
+
+// tabs already loaded for the shown card
+var loadedTabs = make(map[int]bool)
+
 var CardsMap = make(map[int]deck_structs.Card)
 var EditableCard = deck_structs.Card{}
 
+// version of the shown card last loaded from or saved to the server, base of the edit conflict checks
+var loadedCard = deck_structs.Card{}
+
+// card saves run one at a time, lastSaved is the version returned by the last one, base of the next save
+// while loadedCard is not yet refreshed with it
+var saveMutex sync.Mutex
+var lastSaved deck_structs.Card
+
+// index of the highlighted task list item in the card viewer, -1 if none
+var selectedTask = -1
+
+// set while the link picker shows link numbers in the card viewer
+var linkHints = false
+
+// ids of the cards visited following #id references
+var backHistory = make([]int, 0)
+var forwardHistory = make([]int, 0)
+
+// members of the groups and circles assigned to cards, by memberKey
+var members = make(map[string][]string)
+
+// HideDone hides the done cards from the stack lists
+var HideDone = false
+
+// SwitchBoard loads another board, used to follow references to cards of other boards
+var SwitchBoard func(boardId int) error
+
 var currentBoard deck_structs.Board
 
 var app *tview.Application
@@ -43,100 +96,158 @@ func Init(application *tview.Application, conf utils.Configuration, board deck_s
 
 	Modal = tview.NewModal()
 	currentBoard = board
+
+	cardHeader = tview.NewTextView()
+	cardHeader.SetBorder(true)
+	cardHeader.SetBorderColor(utils.GetColor(configuration.Color))
+	cardHeader.SetDynamicColors(true)
+	cardTabs = tview.NewTextView()
+	cardTabs.SetDynamicColors(true)
+	CardFlex = tview.NewFlex()
+	CardFlex.SetDirection(tview.FlexRow)
+
+	deck_comment.Back = func(err error) {
+		showTab(commentsTab, err)
+	}
+
+	deck_markdown.CardResolver = describeCard
+	deck_link.OpenCard = OpenCardReference
+	deck_link.CardUrl = func(cardId int) string {
+		_, boardId, _, ok := findCard(cardId)
+		if !ok {
+			boardId = currentBoard.Id
+		}
+		return utils.GetCardUrl(configuration, boardId, cardId)
+	}
 }
 
 func SetCurrentBoard(board deck_structs.Board) {
 	currentBoard = board
 }
 
+// CanEdit reports if the cards of the current board can be changed, explaining in the footer why not
+func CanEdit(action string) bool {
+	if currentBoard.CanEdit() {
+		return true
+	}
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Can't %s: the board is shared with you read-only", action))
+	return false
+}
+
+// CanManage reports if the stacks and labels of the current board can be changed, explaining in the footer why not
+func CanManage(action string) bool {
+	if currentBoard.CanManage() {
+		return true
+	}
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Can't %s: you are not allowed to manage this board", action))
+	return false
+}
+
 func BuildCardViewer() {
 	DetailText.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if deck_link.IsPicking() {
+			return deck_link.HandleKey(event)
+		}
 		if event.Key() == tcell.KeyEscape {
 			// ESC -> back to main view
+			backHistory = backHistory[:0]
+			forwardHistory = forwardHistory[:0]
 			deck_ui.BuildFullFlex(deck_ui.MainFlex, nil)
+		} else if switchTab(event) {
+			// 1-4 -> switch tab
+			return nil
+		} else if event.Rune() == 91 {
+			// [ -> previous card
+			navigate(&backHistory, &forwardHistory)
+			return nil
+		} else if event.Rune() == 93 {
+			// ] -> next card
+			navigate(&forwardHistory, &backHistory)
+			return nil
 		} else if event.Rune() == 101 {
 			// e -> edit description
-			DetailEditText.SetTitle(fmt.Sprintf(" %s- EDIT", DetailText.GetTitle()))
+			if !CanEdit("edit the card") {
+				return nil
+			}
+			DetailEditText.SetTitle(fmt.Sprintf(" %s- EDIT", cardHeader.GetTitle()))
 			DetailEditText.SetText(utils.FormatDescription(EditableCard.Description), true)
 			deck_ui.BuildFullFlex(DetailEditText, nil)
 
+		} else if event.Key() == tcell.KeyTab {
+			// TAB -> next task
+			selectTask(1)
+			return nil
+		} else if event.Key() == tcell.KeyBacktab {
+			// shift + TAB -> previous task
+			selectTask(-1)
+			return nil
+		} else if event.Rune() == 32 {
+			// space -> toggle selected task
+			if !CanEdit("check tasks") {
+				return nil
+			}
+			toggleTask()
+			return nil
+		} else if event.Rune() == 69 {
+			// E -> edit description in $EDITOR
+			if !CanEdit("edit the card") {
+				return nil
+			}
+			description, changed, err := deck_ui.OpenEditor(utils.FormatDescription(EditableCard.Description))
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error running editor: %s", err.Error()))
+				return nil
+			}
+			if !changed {
+				deck_ui.FooterBar.SetText("Description not changed")
+				return nil
+			}
+			EditableCard.Description = description
+			go editCard("Description saved")
+			CardsMap[EditableCard.Id] = EditableCard
+			updateStacks()
+			DetailText.SetText(renderDetail(EditableCard))
+			deck_ui.FooterBar.SetText("Saving description...")
+			return nil
+		} else if event.Rune() == 111 {
+			// o -> open link
+			pickLink(false)
+			return nil
+		} else if event.Rune() == 121 {
+			// y -> copy link
+			pickLink(true)
+			return nil
+		} else if event.Rune() == 89 {
+			// Y -> copy card web url
+			CopyCardUrl(EditableCard.Id)
+			return nil
+		} else if event.Rune() == 120 {
+			// x -> archive / unarchive card
+			if CanEdit("archive cards") {
+				ArchiveCard(EditableCard, !EditableCard.Archived, nil)
+				app.SetFocus(DetailText)
+			}
+			return nil
+		} else if event.Rune() == 68 {
+			// D -> mark done / not done
+			if CanEdit("mark cards done") {
+				SetCardDone(EditableCard, len(EditableCard.Done) == 0)
+				app.SetFocus(DetailText)
+			}
+			return nil
+		} else if event.Rune() == 65 {
+			// A -> activity
+			showTab(activityTab, nil)
+			return nil
 		} else if event.Rune() == 99 {
 			// c -> comments
-			cardId := utils.GetId(DetailText.GetTitle())
-			deck_comment.GetComments(cardId)
-			deck_comment.CommentTree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-				if event.Key() == tcell.KeyEscape {
-					// ESC -> back to main view
-					deck_ui.BuildFullFlex(DetailText, nil)
-					return nil
-				}
-				if event.Key() == tcell.KeyTAB {
-					return nil
-				}
-				if event.Key() == tcell.KeyRight {
-					return nil
-				}
-				if event.Key() == tcell.KeyLeft {
-					return nil
-				}
-				if event.Rune() == 97 {
-					// a -> add comment
-					addForm, comment := deck_comment.BuildAddForm(deck_structs.Comment{})
-					addForm.AddButton("Save", func() {
-						err := deck_comment.AddComment(cardId, *comment)
-						deck_comment.CreateCommentsTree()
-						deck_ui.BuildFullFlex(deck_comment.CommentTree, err)
-					})
-					deck_ui.BuildFullFlex(addForm, nil)
-					return nil
-				} else if event.Rune() == 100 {
-					// d -> delete comment
-					commentId := deck_comment.CommentTree.GetCurrentNode().GetReference().(int)
-					deck_comment.DeleteComment(cardId, commentId)
-					return nil
-				} else if event.Rune() == 114 {
-					// r -> reply comment
-					parentId := deck_comment.CommentTree.GetCurrentNode().GetReference().(int)
-					addForm, comment := deck_comment.BuildAddForm(deck_structs.Comment{})
-					addForm.AddButton("Save", func() {
-						err := deck_comment.ReplyComment(cardId, parentId, *comment)
-						deck_comment.CreateCommentsTree()
-						deck_ui.BuildFullFlex(deck_comment.CommentTree, err)
-					})
-					deck_ui.BuildFullFlex(addForm, nil)
-					return nil
-				} else if event.Rune() == 101 {
-					// e -> edit comment
-					commentId := deck_comment.CommentTree.GetCurrentNode().GetReference().(int)
-					comment := deck_comment.CommentsMap[commentId]
-					editForm, editComment := deck_comment.BuildAddForm(comment)
-					editForm.AddButton("Save", func() {
-						go func() {
-							err := deck_comment.EditComment(cardId, *editComment)
-							if err != nil {
-								deck_ui.FooterBar.SetText(fmt.Sprintf("Error editing new comment: %s", err.Error()))
-							}
-						}()
-						deck_comment.CreateCommentsTree()
-						deck_ui.BuildFullFlex(deck_comment.CommentTree, nil)
-					})
-					deck_ui.BuildFullFlex(editForm, nil)
-					return nil
-				} else if event.Rune() == 63 {
-					// ? -> help
-					deck_ui.BuildHelp(deck_comment.CommentTree, deck_help.HelpComments)
-					return nil
-				}
-				return event
-			})
-
-			deck_comment.CreateCommentsTree()
-
-			deck_comment.CommentTree.SetTitle(fmt.Sprintf(" %s- COMMENTS ", DetailText.GetTitle()))
-			deck_ui.BuildFullFlex(deck_comment.CommentTree, nil)
-
+			showTab(commentsTab, nil)
+			return nil
 		} else if event.Rune() == 108 {
 			// l -> labels
+			if !CanEdit("change the labels") {
+				return nil
+			}
 			EditTagsFlex.Clear()
 			actualLabelList := tview.NewList()
 			actualLabelList.SetBorder(true)
@@ -202,13 +313,13 @@ func BuildCardViewer() {
 			EditTagsFlex.SetDirection(tview.FlexColumn)
 			EditTagsFlex.SetBorder(true)
 			EditTagsFlex.SetBorderColor(utils.GetColor(configuration.Color))
-			EditTagsFlex.SetTitle(fmt.Sprintf(" %s- EDIT TAGS ", DetailText.GetTitle()))
+			EditTagsFlex.SetTitle(fmt.Sprintf(" %s- EDIT TAGS ", cardHeader.GetTitle()))
 
 			EditTagsFlex.AddItem(actualLabelList, 0, 1, true)
 			EditTagsFlex.AddItem(labelList, 0, 1, true)
 			EditTagsFlex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 				if event.Key() == tcell.KeyEsc {
-					deck_ui.BuildFullFlex(DetailText, nil)
+					showTab(descriptionTab, nil)
 					return nil
 				}
 				if event.Key() == tcell.KeyTab {
@@ -229,6 +340,9 @@ func BuildCardViewer() {
 
 		} else if event.Rune() == 117 {
 			// u -> edit users
+			if !CanEdit("assign users") {
+				return nil
+			}
 			EditUsersFlex.Clear()
 			actualUserList := tview.NewList()
 			actualUserList.SetBorder(true)
@@ -240,13 +354,13 @@ func BuildCardViewer() {
 				return event
 			})
 			for _, user := range EditableCard.AssignedUsers {
-				actualUserList.AddItem(fmt.Sprintf("%s", user.Participant.DisplayName), "",
+				actualUserList.AddItem(buildAssigneeItem(user), "",
 					rune(0), nil)
 			}
 			actualUserList.SetSelectedFunc(func(index int, name string, secondName string, rune rune) {
 				user := EditableCard.AssignedUsers[index]
 				// delete user
-				jsonBody := fmt.Sprintf(`{"userId": "%s"}`, user.Participant.Uid)
+				jsonBody := fmt.Sprintf(`{"userId": "%s", "type": %d}`, user.Participant.Uid, user.Type)
 				go DeleteUser(jsonBody)
 				EditableCard.AssignedUsers = append(EditableCard.AssignedUsers[:index], EditableCard.AssignedUsers[index+1:]...)
 				CardsMap[EditableCard.Id] = EditableCard
@@ -267,36 +381,30 @@ func BuildCardViewer() {
 				}
 				return event
 			})
-			for _, user := range currentBoard.Users {
-				userList.AddItem(fmt.Sprintf("%s", user.DisplayName), "",
+			assignees := boardAssignees()
+			for _, user := range assignees {
+				userList.AddItem(buildAssigneeItem(user), "",
 					rune(0), nil)
 			}
 
 			userList.SetSelectedFunc(func(index int, name string, secondName string, rune rune) {
-				user := currentBoard.Users[index]
+				au := assignees[index]
 
 				for _, u := range EditableCard.AssignedUsers {
-					if u.Participant.Uid == user.Uid {
-						deck_ui.FooterBar.SetText("user already assigned")
+					if u.Participant.Uid == au.Participant.Uid && u.Type == au.Type {
+						deck_ui.FooterBar.SetText(fmt.Sprintf("%s already assigned", deck_structs.ParticipantTypeName(au.Type)))
 						return
 					}
 				}
 
-				jsonBody := fmt.Sprintf(`{"userId": "%s" }`, user.Uid)
+				jsonBody := fmt.Sprintf(`{"userId": "%s", "type": %d}`, au.Participant.Uid, au.Type)
 				go AssignUser(jsonBody)
 
-				au := deck_structs.AssignedUser{
-					CardId: EditableCard.Id,
-					Type:   0,
-					Participant: deck_structs.Owner{
-						PrimaryKey:  user.PrimaryKey,
-						Uid:         user.Uid,
-						DisplayName: user.DisplayName,
-					},
-				}
+				au.CardId = EditableCard.Id
 				EditableCard.AssignedUsers = append(EditableCard.AssignedUsers, au)
 				CardsMap[EditableCard.Id] = EditableCard
-				actualUserList.AddItem(fmt.Sprintf("%s", user.DisplayName), "",
+				loadMembers(EditableCard)
+				actualUserList.AddItem(buildAssigneeItem(au), "",
 					rune, nil)
 				updateStacks()
 				BuildStacks()
@@ -306,13 +414,13 @@ func BuildCardViewer() {
 			EditUsersFlex.SetDirection(tview.FlexColumn)
 			EditUsersFlex.SetBorder(true)
 			EditUsersFlex.SetBorderColor(utils.GetColor(configuration.Color))
-			EditUsersFlex.SetTitle(fmt.Sprintf(" %s- EDIT Users ", DetailText.GetTitle()))
+			EditUsersFlex.SetTitle(fmt.Sprintf(" %s- EDIT Users ", cardHeader.GetTitle()))
 
 			EditUsersFlex.AddItem(actualUserList, 0, 1, true)
 			EditUsersFlex.AddItem(userList, 0, 1, true)
 			EditUsersFlex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 				if event.Key() == tcell.KeyEsc {
-					deck_ui.BuildFullFlex(DetailText, nil)
+					showTab(descriptionTab, nil)
 					return nil
 				}
 				if event.Key() == tcell.KeyTab {
@@ -333,53 +441,589 @@ func BuildCardViewer() {
 
 		} else if event.Rune() == 116 {
 			// t -> edit detail
+			if !CanEdit("edit the card") {
+				return nil
+			}
 			var form *tview.Form
+			originalDueDate := EditableCard.DueDate
 			form, card := BuildDetailForm(&EditableCard)
 			EditableCard = *card
 
 			form.AddButton("Save", func() {
-				go editCard()
-				if len(EditableCard.DueDate) > 0 {
-					pattern := "02/01/2006 15:04"
-					parse, _ := time.Parse(pattern, EditableCard.DueDate)
-					EditableCard.DueDate = parse.Format("2006-01-02T15:04:05+00:00")
+				// an untouched due date is sent back as it was
+				dueDate, err := deck_date.UpdateToApi(EditableCard.DueDate, originalDueDate)
+				if err != nil {
+					deck_ui.FooterBar.SetText(fmt.Sprintf("Not a valid date: %s", err.Error()))
+					return
 				}
+				EditableCard.DueDate = dueDate
+				go editCard("")
 				CardsMap[EditableCard.Id] = EditableCard
-				DetailText.SetTitle(fmt.Sprintf(" %s ", EditableCard.Title))
+				DetailText.SetText(renderDetail(EditableCard))
 				updateStacks()
 				BuildStacks()
-				deck_ui.BuildFullFlex(DetailText, nil)
+				showTab(descriptionTab, nil)
 			})
 			deck_ui.BuildFullFlex(form, nil)
 		} else if event.Rune() == 63 {
 			// ? -> deck_help menu
-			deck_ui.BuildHelp(DetailText, deck_help.HelpView)
+			deck_ui.BuildHelp(CardFlex, deck_help.HelpView)
 		}
 		return event
 	})
 
+	deck_comment.CommentTree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if deck_link.IsPicking() {
+			return deck_link.HandleKey(event)
+		}
+		cardId := EditableCard.Id
+		if event.Key() == tcell.KeyEscape {
+			// ESC -> back to description
+			showTab(descriptionTab, nil)
+			return nil
+		}
+		if switchTab(event) {
+			return nil
+		}
+		if event.Key() == tcell.KeyTAB {
+			return nil
+		}
+		if event.Rune() == 97 {
+			// a -> add comment
+			addForm, comment := deck_comment.BuildAddForm(deck_structs.Comment{})
+			addForm.AddButton("Save", func() {
+				err := deck_comment.AddComment(cardId, *comment)
+				if err == nil {
+					EditableCard.CommentsCount++
+				}
+				deck_comment.CreateCommentsTree()
+				showTab(commentsTab, err)
+			})
+			deck_ui.BuildFullFlex(addForm, nil)
+			return nil
+		} else if event.Rune() == 100 {
+			// d -> delete comment
+			commentId := deck_comment.GetSelectedId()
+			if commentId == 0 {
+				return nil
+			}
+			deck_comment.DeleteComment(cardId, commentId)
+			return nil
+		} else if event.Rune() == 114 {
+			// r -> reply comment
+			parentId := deck_comment.GetSelectedId()
+			if parentId == 0 {
+				return nil
+			}
+			addForm, comment := deck_comment.BuildAddForm(deck_structs.Comment{})
+			addForm.AddButton("Save", func() {
+				err := deck_comment.ReplyComment(cardId, parentId, *comment)
+				if err == nil {
+					EditableCard.CommentsCount++
+				}
+				deck_comment.CreateCommentsTree()
+				showTab(commentsTab, err)
+			})
+			deck_ui.BuildFullFlex(addForm, nil)
+			return nil
+		} else if event.Rune() == 101 {
+			// e -> edit comment
+			commentId := deck_comment.GetSelectedId()
+			if commentId == 0 {
+				return nil
+			}
+			comment := deck_comment.CommentsMap[commentId]
+			editForm, editComment := deck_comment.BuildAddForm(comment)
+			editForm.AddButton("Save", func() {
+				go func() {
+					err := deck_comment.EditComment(cardId, *editComment)
+					if err != nil {
+						deck_ui.FooterBar.SetText(fmt.Sprintf("Error editing new comment: %s", err.Error()))
+					}
+				}()
+				deck_comment.CreateCommentsTree()
+				showTab(commentsTab, nil)
+			})
+			deck_ui.BuildFullFlex(editForm, nil)
+			return nil
+		} else if event.Rune() == 111 || event.Rune() == 121 {
+			// o -> open link, y -> copy link
+			deck_link.Pick(deck_comment.ShowLinkHints(true), event.Rune() == 121, func() {
+				deck_comment.ShowLinkHints(false)
+			})
+			return nil
+		} else if event.Rune() == 89 {
+			// Y -> copy card web url
+			CopyCardUrl(cardId)
+			return nil
+		} else if event.Rune() == 63 {
+			// ? -> help
+			deck_ui.BuildHelp(CardFlex, deck_help.HelpComments)
+			return nil
+		}
+		return deck_comment.HandleKey(event)
+	})
+
 	DetailEditText.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEscape {
 			DetailText.Clear()
-			DetailText.SetTitle(fmt.Sprintf(" %s ", EditableCard.Title))
-			DetailText.SetText(deck_markdown.GetMarkDownDescription(utils.FormatDescription(EditableCard.Description), configuration))
-			deck_ui.BuildFullFlex(DetailText, nil)
+			DetailText.SetText(renderDetail(EditableCard))
+			showTab(descriptionTab, nil)
 		} else if event.Key() == tcell.KeyF2 {
 			EditableCard.Description = DetailEditText.GetText()
-			go editCard()
+			go editCard("")
 			CardsMap[EditableCard.Id] = EditableCard
-			DetailText.SetText(deck_markdown.GetMarkDownDescription(utils.FormatDescription(EditableCard.Description), configuration))
-			deck_ui.BuildFullFlex(DetailText, nil)
+			DetailText.SetText(renderDetail(EditableCard))
+			showTab(descriptionTab, nil)
 		}
 		return event
 	})
 	DetailText.SetBorder(true)
 	DetailText.SetBorderColor(utils.GetColor(configuration.Color))
+	DetailText.SetRegions(true)
+
+	tabCapture := func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			// ESC -> back to description
+			showTab(descriptionTab, nil)
+			return nil
+		} else if switchTab(event) {
+			return nil
+		} else if event.Rune() == 63 {
+			// ? -> help
+			deck_ui.BuildHelp(CardFlex, deck_help.HelpView)
+			return nil
+		}
+		return event
+	}
+	deck_attachment.AttachmentView.SetInputCapture(tabCapture)
+	deck_activity.ActivityView.SetInputCapture(tabCapture)
 
 	DetailEditText.SetBorder(true)
 	DetailEditText.SetBorderColor(utils.GetColor(configuration.Color))
 }
 
+func ShowCard(card deck_structs.Card) {
+	DetailText.SetDynamicColors(true)
+	DetailText.SetText(renderDetail(card))
+	EditableCard = card
+	loadedCard = card
+	selectedTask = -1
+	loadedTabs = make(map[int]bool)
+	DetailText.Highlight()
+	loadMembers(card)
+	showTab(descriptionTab, nil)
+}
+
+// showTab shows the card detail screen with the given tab, loading its content the first time
+func showTab(tab int, err error) {
+	currentTab = tab
+	var content tview.Primitive
+	switch tab {
+	case commentsTab:
+		if !loadedTabs[tab] {
+			deck_comment.GetComments(EditableCard.Id)
+			deck_comment.Users = mentionableUsers(EditableCard)
+			deck_comment.CreateCommentsTree()
+			if EditableCard.CommentsUnread > 0 {
+				// comments have been read, drop the unread badge
+				EditableCard.CommentsUnread = 0
+				UpdateLocalCard(EditableCard)
+				BuildStacks()
+			}
+		}
+		content = deck_comment.CommentTree
+	case attachmentsTab:
+		if !loadedTabs[tab] {
+			deck_attachment.LoadAttachments(currentBoard.Id, EditableCard.StackId, EditableCard.Id)
+		}
+		content = deck_attachment.AttachmentView
+	case activityTab:
+		if !loadedTabs[tab] {
+			deck_activity.LoadActivity(EditableCard.Id)
+		}
+		content = deck_activity.ActivityView
+	default:
+		content = DetailText
+	}
+	loadedTabs[tab] = true
+
+	cardHeader.SetTitle(fmt.Sprintf(" #%d - %s ", EditableCard.Id, EditableCard.Title))
+	header := renderHeader(EditableCard)
+	cardHeader.SetText(header)
+	cardTabs.SetText(renderTabs(EditableCard))
+
+	CardFlex.Clear()
+	CardFlex.AddItem(cardHeader, strings.Count(header, "\n")+3, 0, false)
+	CardFlex.AddItem(cardTabs, 1, 0, false)
+	CardFlex.AddItem(content, 0, 1, true)
+	deck_ui.BuildFullFlex(CardFlex, err)
+}
+
+// switchTab shows the tab of the number key pressed, reports if the key selected a tab
+func switchTab(event *tcell.EventKey) bool {
+	tab := int(event.Rune() - '1')
+	if event.Key() != tcell.KeyRune || tab < 0 || tab >= len(tabNames) {
+		return false
+	}
+	showTab(tab, nil)
+	return true
+}
+
+// renderTabs returns the tab bar, the current tab highlighted
+func renderTabs(card deck_structs.Card) string {
+	tabs := make([]string, 0)
+	for i, name := range tabNames {
+		if i == commentsTab && card.CommentsUnread > 0 {
+			name = fmt.Sprintf("%s (%d, %d new)", name, card.CommentsCount, card.CommentsUnread)
+		} else if i == commentsTab && card.CommentsCount > 0 {
+			name = fmt.Sprintf("%s (%d)", name, card.CommentsCount)
+		} else if i == attachmentsTab && card.AttachmentCount > 0 {
+			name = fmt.Sprintf("%s (%d)", name, card.AttachmentCount)
+		}
+		if i == currentTab {
+			tabs = append(tabs, fmt.Sprintf("[black:%s:b] %d %s [-:-:-]", configuration.Color, i+1, name))
+		} else {
+			tabs = append(tabs, fmt.Sprintf("[gray] %d %s [-]", i+1, name))
+		}
+	}
+	return " " + strings.Join(tabs, " ")
+}
+
+// renderHeader returns the card metadata: stack, due date, labels, assignees, owner and dates
+func renderHeader(card deck_structs.Card) string {
+	now := time.Now()
+	field := func(name string, value string) string {
+		return fmt.Sprintf("[%s::b]%s:[-::-] %s", configuration.Color, name, value)
+	}
+
+	first := make([]string, 0)
+	for _, stack := range deck_stack.Stacks {
+		if stack.Id == card.StackId {
+			first = append(first, field("Stack", tview.Escape(stack.Title)))
+			break
+		}
+	}
+	if due, err := deck_date.FromApi(card.DueDate); err == nil && len(card.DueDate) > 0 {
+		status := deck_date.GetDueStatus(card, now)
+		first = append(first, field("Due", fmt.Sprintf("[%s]%s (%s)[-]", deck_date.GetStatusColor(status),
+			due.Local().Format("Mon "+deck_date.GetDisplayLayout()), deck_date.Relative(due, now))))
+	}
+	comments := strconv.Itoa(card.CommentsCount)
+	if card.CommentsUnread > 0 {
+		comments = fmt.Sprintf("%s [black:yellow] %d new [-:-:-]", comments, card.CommentsUnread)
+	}
+	first = append(first, field("Comments", comments))
+	if len(card.Done) > 0 {
+		first = append(first, fmt.Sprintf("[black:green] DONE [-:-:-] [gray]%s[-]", deck_date.FormatApi(card.Done)))
+	}
+	if card.Archived {
+		first = append(first, "[black:gray] ARCHIVED [-:-:-]")
+	}
+	if !currentBoard.CanEdit() {
+		first = append(first, "[black:gray] READ-ONLY [-:-:-]")
+	}
+
+	labels := make([]string, 0)
+	for _, l := range card.Labels {
+		labels = append(labels, fmt.Sprintf("[#%s]%s[-]", l.Color, tview.Escape(l.Title)))
+	}
+	assigned := make([]string, 0)
+	groups := make([]string, 0)
+	for _, u := range card.AssignedUsers {
+		if u.Type == deck_structs.ParticipantUser {
+			assigned = append(assigned, tview.Escape(u.Participant.DisplayName))
+			continue
+		}
+		assigned = append(assigned, buildAssigneeItem(u))
+		names, loaded := members[memberKey(u)]
+		switch {
+		case !loaded:
+			names = []string{"[gray]loading...[-]"}
+		case len(names) == 0:
+			names = []string{"[gray]not visible[-]"}
+		}
+		groups = append(groups, fmt.Sprintf("[::u]%s[::-] %s", tview.Escape(u.Participant.DisplayName), strings.Join(names, ", ")))
+	}
+	second := []string{field("Labels", orNone(labels, " ")), field("Assigned", orNone(assigned, ", "))}
+
+	third := make([]string, 0)
+	if len(card.Owner.DisplayName) > 0 {
+		third = append(third, field("Owner", tview.Escape(card.Owner.DisplayName)))
+	}
+	if card.CreatedAt > 0 {
+		created := time.Unix(card.CreatedAt, 0)
+		third = append(third, field("Created", fmt.Sprintf("%s (%s)", deck_date.Format(created), deck_date.Ago(created, now))))
+	}
+	if card.LastModified > 0 {
+		modified := time.Unix(card.LastModified, 0)
+		third = append(third, field("Modified", fmt.Sprintf("%s (%s)", deck_date.Format(modified), deck_date.Ago(modified, now))))
+	}
+
+	lines := []string{strings.Join(first, "   "), strings.Join(second, "   ")}
+	if len(groups) > 0 {
+		lines = append(lines, field("Members", strings.Join(groups, "   ")))
+	}
+	if len(third) > 0 {
+		lines = append(lines, strings.Join(third, "   "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// refreshHeader renders the header of the card detail screen again, resizing it to its lines
+func refreshHeader() {
+	header := renderHeader(EditableCard)
+	cardHeader.SetText(header)
+	CardFlex.ResizeItem(cardHeader, strings.Count(header, "\n")+3, 0)
+}
+
+// buildAssigneeItem returns the name of an assigned user, groups and circles are marked with their type
+func buildAssigneeItem(user deck_structs.AssignedUser) string {
+	if user.Type == deck_structs.ParticipantUser {
+		return tview.Escape(user.Participant.DisplayName)
+	}
+	return fmt.Sprintf("[%s]%s[-] [gray](%s)[-]", configuration.Color, tview.Escape(user.Participant.DisplayName),
+		deck_structs.ParticipantTypeName(user.Type))
+}
+
+// boardAssignees returns the users of the board and the groups and circles it is shared with
+func boardAssignees() []deck_structs.AssignedUser {
+	assignees := make([]deck_structs.AssignedUser, 0)
+	for _, user := range currentBoard.Users {
+		assignees = append(assignees, deck_structs.AssignedUser{Type: deck_structs.ParticipantUser, Participant: user})
+	}
+	for _, acl := range currentBoard.Acl {
+		if acl.Type == deck_structs.ParticipantGroup || acl.Type == deck_structs.ParticipantCircle {
+			assignees = append(assignees, deck_structs.AssignedUser{Type: acl.Type, Participant: acl.Participant})
+		}
+	}
+	return assignees
+}
+
+func memberKey(user deck_structs.AssignedUser) string {
+	return fmt.Sprintf("%d:%s", user.Type, user.Participant.Uid)
+}
+
+// loadMembers fetches the members of the groups and circles assigned to a card, updating the header when done
+func loadMembers(card deck_structs.Card) {
+	for _, u := range card.AssignedUsers {
+		if u.Type == deck_structs.ParticipantUser {
+			continue
+		}
+		if _, loaded := members[memberKey(u)]; loaded {
+			continue
+		}
+		go func(u deck_structs.AssignedUser) {
+			names := make([]string, 0)
+			if u.Type == deck_structs.ParticipantGroup {
+				// groups members are visible to admins only, other users get an empty list
+				uids, err := deck_http.GetGroupMembers(u.Participant.Uid, configuration)
+				if err == nil {
+					for _, uid := range uids {
+						names = append(names, tview.Escape(displayName(uid)))
+					}
+				}
+			} else {
+				circleMembers, err := deck_http.GetCircleMembers(u.Participant.Uid, configuration)
+				if err == nil {
+					for _, m := range circleMembers {
+						names = append(names, tview.Escape(m.DisplayName))
+					}
+				}
+			}
+			app.QueueUpdateDraw(func() {
+				members[memberKey(u)] = names
+				if EditableCard.Id == card.Id {
+					refreshHeader()
+				}
+			})
+		}(u)
+	}
+}
+
+// displayName returns the name of a user of the board, or the uid for unknown users
+func displayName(uid string) string {
+	for _, u := range currentBoard.Users {
+		if u.Uid == uid {
+			return u.DisplayName
+		}
+	}
+	return uid
+}
+
+// orNone joins values with sep, or returns a gray "none" for an empty list
+func orNone(values []string, sep string) string {
+	if len(values) == 0 {
+		return "[gray]none[-]"
+	}
+	return strings.Join(values, sep)
+}
+
+// pickLink numbers the links of the card description and starts the link picker
+func pickLink(copy bool) {
+	linkHints = true
+	DetailText.SetText(renderDetail(EditableCard))
+	links := deck_markdown.GetLinks(utils.FormatDescription(EditableCard.Description))
+	started := deck_link.Pick(links, copy, func() {
+		linkHints = false
+		DetailText.SetText(renderDetail(EditableCard))
+		if selectedTask >= 0 {
+			DetailText.Highlight(fmt.Sprintf("task-%d", selectedTask))
+		}
+	})
+	if !started {
+		linkHints = false
+		DetailText.SetText(renderDetail(EditableCard))
+	}
+}
+
+// OpenCardReference shows the card referenced by #id, switching board if needed
+func OpenCardReference(cardId int) {
+	previous := EditableCard.Id
+	if cardId == previous {
+		return
+	}
+	if showCardById(cardId) {
+		backHistory = append(backHistory, previous)
+		forwardHistory = forwardHistory[:0]
+	}
+}
+
+// navigate shows the last card of from, moving the current card to to
+func navigate(from *[]int, to *[]int) {
+	if len(*from) == 0 {
+		deck_ui.FooterBar.SetText("No more cards in history")
+		return
+	}
+	current := EditableCard.Id
+	cardId := (*from)[len(*from)-1]
+	if showCardById(cardId) {
+		*from = (*from)[:len(*from)-1]
+		*to = append(*to, current)
+	}
+}
+
+func showCardById(cardId int) bool {
+	_, boardId, _, ok := findCard(cardId)
+	if !ok {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d not found", cardId))
+		return false
+	}
+	if boardId != currentBoard.Id {
+		if SwitchBoard == nil || SwitchBoard(boardId) != nil {
+			return false
+		}
+	}
+	card, _, _, ok := findCard(cardId)
+	if !ok {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d not found", cardId))
+		return false
+	}
+	ShowCard(card)
+	return true
+}
+
+// findCard looks for a card in the current board, then in the cached stacks of the other boards
+func findCard(cardId int) (deck_structs.Card, int, deck_structs.Stack, bool) {
+	for _, s := range deck_stack.Stacks {
+		for _, c := range s.Cards {
+			if c.Id == cardId {
+				return c, currentBoard.Id, s, true
+			}
+		}
+	}
+	return deck_db.FindCard(cardId, configuration)
+}
+
+// mentionableUsers returns the users of the board, the card assignees first
+func mentionableUsers(card deck_structs.Card) []deck_structs.Owner {
+	users := make([]deck_structs.Owner, 0)
+	added := make(map[string]bool)
+	for _, u := range card.AssignedUsers {
+		if u.Type == deck_structs.ParticipantUser && !added[u.Participant.Uid] {
+			users = append(users, u.Participant)
+			added[u.Participant.Uid] = true
+		}
+	}
+	for _, u := range currentBoard.Users {
+		if !added[u.Uid] {
+			users = append(users, u)
+			added[u.Uid] = true
+		}
+	}
+	return users
+}
+
+// describeCard returns the title and the stack of a referenced card
+func describeCard(cardId int) (string, bool) {
+	card, _, stack, ok := findCard(cardId)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s · %s", card.Title, stack.Title), true
+}
+
+// CopyCardUrl copies the link to the card in the deck web ui to the clipboard
+func CopyCardUrl(cardId int) {
+	url := utils.GetCardUrl(configuration, currentBoard.Id, cardId)
+	err := deck_link.Copy(url)
+	if err != nil {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Error copying card url: %s", err.Error()))
+		return
+	}
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Copied to clipboard: %s", url))
+}
+
+// selectTask moves the task cursor by offset, wrapping around the task list items
+func selectTask(offset int) {
+	_, total := deck_markdown.CountTasks(utils.FormatDescription(EditableCard.Description))
+	if total == 0 {
+		return
+	}
+	selectedTask = ((selectedTask+offset)%total + total) % total
+	DetailText.Highlight(fmt.Sprintf("task-%d", selectedTask)).ScrollToHighlight()
+}
+
+// toggleTask checks or unchecks the highlighted task and saves the card
+func toggleTask() {
+	if selectedTask < 0 {
+		return
+	}
+	description, ok := deck_markdown.ToggleTask(utils.FormatDescription(EditableCard.Description), selectedTask)
+	if !ok {
+		return
+	}
+	EditableCard.Description = description
+	go editCard("")
+	CardsMap[EditableCard.Id] = EditableCard
+	updateStacks()
+	BuildStacks()
+	DetailText.SetText(renderDetail(EditableCard))
+	DetailText.Highlight(fmt.Sprintf("task-%d", selectedTask))
+	app.SetFocus(DetailText)
+}
+
+// renderDetail returns the card description, the due date is shown in the header
+func renderDetail(card deck_structs.Card) string {
+	if linkHints {
+		return deck_markdown.GetMarkDownDescriptionHints(utils.FormatDescription(card.Description), configuration, 0)
+	}
+	return deck_markdown.GetMarkDownDescriptionRegions(utils.FormatDescription(card.Description), configuration)
+}
+
+// UpdateLocalCard replaces a card in the loaded stacks and in CardsMap
+func UpdateLocalCard(card deck_structs.Card) {
+	CardsMap[card.Id] = card
+	for i, s := range deck_stack.Stacks {
+		for j, c := range s.Cards {
+			if c.Id == card.Id {
+				deck_stack.Stacks[i].Cards[j] = card
+				return
+			}
+		}
+	}
+}
+
 func updateStacks() {
 	for i, s := range deck_stack.Stacks {
 		if s.Id == EditableCard.StackId {
@@ -422,28 +1066,157 @@ func moveCardToStack(todoList *tview.List, primitive *tview.Primitive, key tcell
 
 	nextStack := deck_stack.Stacks[actualPrimitiveIndex+operator]
 
-	jsonBody := fmt.Sprintf(`{"stackId": "%d", "title": "%s", "type": "plain", "owner":"%s"}`,
-		nextStack.Id, utils.CleanText(card.Title), configuration.User)
-
-	go updateCard(currentBoard.Id, card.StackId, card.Id, jsonBody)
+	fromStackId := card.StackId
+	moved := card
+	moved.StackId = nextStack.Id
+	// the update replaces all the fields, send the description and the due date too
+	jsonBody := cardUpdateJson(moved)
+	go func() {
+		updateCard(currentBoard.Id, fromStackId, card.Id, jsonBody)
+		// the card is shown at the top of the stack, put it there on the server too
+		reorderCard(currentBoard.Id, nextStack.Id, card.Id, 0)
+	}()
 
 	var labels = utils.BuildLabels(card)
 	card.StackId = nextStack.Id
 	CardsMap[card.Id] = card
 
+	currentStack := &deck_stack.Stacks[actualPrimitiveIndex]
+	for j, c := range currentStack.Cards {
+		if c.Id == card.Id {
+			currentStack.Cards = append(currentStack.Cards[:j], currentStack.Cards[j+1:]...)
+			break
+		}
+	}
+	destStack := &deck_stack.Stacks[actualPrimitiveIndex+operator]
+	destStack.Cards = append([]deck_structs.Card{card}, destStack.Cards...)
+	for j := range destStack.Cards {
+		destStack.Cards[j].Order = j
+		CardsMap[destStack.Cards[j].Id] = destStack.Cards[j]
+	}
+
 	destList := deck_ui.GetNextFocus(actualPrimitiveIndex + operator).(*tview.List)
 	todoList.RemoveItem(i)
+	todoList.SetTitle(buildStackTitle(*currentStack))
+	destList.SetTitle(buildStackTitle(*destStack))
+
+	destList.InsertItem(0, buildCardItem(card), labels, rune(0), nil)
+	destList.SetCurrentItem(0)
+	app.SetFocus(destList)
+}
+
+// moveCardInStack moves the selected card of a stack list up or down, swapping it with the next card shown
+func moveCardInStack(todoList *tview.List, stackIndex int, offset int) {
+	i := todoList.GetCurrentItem()
+	j := i + offset
+	if j < 0 || j >= todoList.GetItemCount() {
+		return
+	}
+	name, secondary := todoList.GetItemText(i)
+	otherName, _ := todoList.GetItemText(j)
+	cardId := utils.GetId(name)
+	otherId := utils.GetId(otherName)
+
+	// the position among all the cards of the stack, done cards may be hidden from the list
+	stack := &deck_stack.Stacks[stackIndex]
+	order := -1
+	cards := make([]deck_structs.Card, 0, len(stack.Cards))
+	var card deck_structs.Card
+	for _, c := range stack.Cards {
+		if c.Id == cardId {
+			card = c
+			continue
+		}
+		cards = append(cards, c)
+	}
+	for k, c := range cards {
+		if c.Id == otherId {
+			order = k
+			if offset > 0 {
+				order++
+			}
+			break
+		}
+	}
+	if order < 0 || card.Id == 0 {
+		return
+	}
+	cards = append(cards[:order], append([]deck_structs.Card{card}, cards[order:]...)...)
+	for k := range cards {
+		cards[k].Order = k
+		CardsMap[cards[k].Id] = cards[k]
+	}
+	stack.Cards = cards
+
+	todoList.RemoveItem(i)
+	todoList.InsertItem(j, name, secondary, rune(0), nil)
+	todoList.SetCurrentItem(j)
+
+	go reorderCard(currentBoard.Id, stack.Id, cardId, order)
+}
+
+// reorderCard moves a card to a position of a stack on the server, then takes the order of the stack cards from the response
+func reorderCard(boardId int, stackId int, cardId int, order int) {
+	jsonBody := fmt.Sprintf(`{"order": %d, "stackId": %d}`, order, stackId)
+	cards, err := deck_http.ReorderCard(boardId, stackId, cardId, jsonBody, configuration)
+	app.QueueUpdateDraw(func() {
+		if err != nil {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error reordering card: %s", err.Error()))
+			return
+		}
+		updated := make(map[int]deck_structs.Card)
+		for _, c := range cards {
+			updated[c.Id] = c
+		}
+		for i, s := range deck_stack.Stacks {
+			if s.Id != stackId {
+				continue
+			}
+			moved := false
+			for j, c := range s.Cards {
+				u, ok := updated[c.Id]
+				if !ok {
+					continue
+				}
+				moved = moved || u.Order != c.Order
+				c.Order = u.Order
+				c.LastModified = u.LastModified
+				c.ETag = u.ETag
+				deck_stack.Stacks[i].Cards[j] = c
+				CardsMap[c.Id] = c
+			}
+			if moved && boardId == currentBoard.Id {
+				// the server put the cards in another order, show it
+				index := 0
+				if list, ok := deck_ui.PrimitivesIndexMap[i].(*tview.List); ok {
+					index = list.GetCurrentItem()
+				}
+				BuildStacks()
+				FocusCard(stackId, cardId, index)
+			}
+		}
+		err = deck_db.SaveStacks(currentBoard.Id, deck_stack.Stacks, configuration)
+		if err != nil {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error saving stacks: %s", err.Error()))
+		}
+	})
+}
 
+// buildCardItem returns the main text of a card in the stack lists
+func buildCardItem(card deck_structs.Card) string {
 	dueDate := ""
 	if len(card.DueDate) > 0 {
-		parse, _ := time.Parse("2006-01-02T15:04:05+00:00", card.DueDate)
-		card.DueDate = parse.Format("02/01/2006 15:04")
-		dueDate = fmt.Sprintf("- [red:-:-](%s)[white]", card.DueDate)
+		dueDate = fmt.Sprintf("- %s", deck_date.FormatDue(card, true))
 	}
 
 	assigners := make([]string, 0)
 	for _, o := range card.AssignedUsers {
-		assigners = append(assigners, o.Participant.GetAbbrv())
+		if o.Type == deck_structs.ParticipantUser {
+			assigners = append(assigners, o.Participant.GetAbbrv())
+		} else {
+			// groups and circles in blue
+			assigners = append(assigners, fmt.Sprintf("[blue]%s[red]", o.Participant.GetAbbrv()))
+		}
 	}
 
 	assignersFormatter := ""
@@ -451,9 +1224,47 @@ func moveCardToStack(todoList *tview.List, primitive *tview.Primitive, key tcell
 		assignersFormatter = fmt.Sprintf("- [red:gray:-]%s[-:-:-] ", utils.CommaString(assigners))
 	}
 
-	destList.InsertItem(0, fmt.Sprintf("[%s]#%d[white] %s- %s %s", configuration.Color, card.Id, assignersFormatter, card.Title, dueDate), labels, rune(0), nil)
-	destList.SetCurrentItem(0)
-	app.SetFocus(destList)
+	title := card.Title
+	if card.Archived {
+		title = fmt.Sprintf("[gray]▣ %s[white]", card.Title)
+	} else if deck_date.GetDueStatus(card, time.Now()) == deck_date.DueDone {
+		title = fmt.Sprintf("[gray]✔ [gray::s]%s[white::-]", card.Title)
+	}
+	if card.CommentsUnread > 0 {
+		title = fmt.Sprintf("%s [black:yellow] %d new [-:-:-]", title, card.CommentsUnread)
+	}
+
+	if done, total := deck_markdown.CountTasks(utils.FormatDescription(card.Description)); total > 0 {
+		color := "gray"
+		if done == total {
+			color = "green"
+		}
+		title = fmt.Sprintf("%s [%s]☑ %d/%d[white]", title, color, done, total)
+	}
+
+	return fmt.Sprintf("[%s]#%d[white] %s- %s %s", configuration.Color, card.Id, assignersFormatter, title, dueDate)
+}
+
+// buildStackTitle returns the stack list title with the number of overdue cards and of hidden done cards
+func buildStackTitle(stack deck_structs.Stack) string {
+	overdue := 0
+	done := 0
+	for _, c := range stack.Cards {
+		if deck_date.GetDueStatus(c, time.Now()) == deck_date.DueOverdue {
+			overdue++
+		}
+		if len(c.Done) > 0 {
+			done++
+		}
+	}
+	title := stack.Title
+	if overdue > 0 {
+		title = fmt.Sprintf("%s [red](%d overdue)[-]", title, overdue)
+	}
+	if HideDone && done > 0 {
+		title = fmt.Sprintf("%s [gray](%d done hidden)[-]", title, done)
+	}
+	return fmt.Sprintf(" %s ", title)
 }
 
 func BuildAddForm() (*tview.Form, *deck_structs.Card) {
@@ -480,17 +1291,7 @@ func BuildAddForm() (*tview.Form, *deck_structs.Card) {
 		card.Description = description
 	})
 
-	addForm.AddInputField("Due Date", "", 18, func(textToCheck string, lastChar rune) bool {
-
-		//re := regexp.MustCompile(`[0-9]{2}/[0-9]{2}/[0-9]{4} [0-9]{2}:[0-9]{2}`)
-		//match := re.FindAllStringSubmatch(textToCheck, -1)
-		//return len(match) > 0
-		if (lastChar >= rune(47) && lastChar <= rune(58)) || lastChar == rune(32) {
-			return true
-		}
-		return false
-
-	}, func(date string) {
+	addDueDateField(addForm, "", func(date string) {
 		card.DueDate = date
 	})
 
@@ -527,24 +1328,9 @@ func BuildDetailForm(card *deck_structs.Card) (*tview.Form, *deck_structs.Card)
 		card.Title = title
 	})
 
-	dueDate := ""
-	if len(card.DueDate) > 0 {
-		parse, _ := time.Parse("2006-01-02T15:04:05+00:00", card.DueDate)
-		card.DueDate = parse.Format("02/01/2006 15:04")
-		dueDate = fmt.Sprintf("%s", card.DueDate)
-	}
-
-	addForm.AddInputField("Due Date", dueDate, 18, func(textToCheck string, lastChar rune) bool {
-
-		//re := regexp.MustCompile(`[0-9]{2}/[0-9]{2}/[0-9]{4} [0-9]{2}:[0-9]{2}`)
-		//match := re.FindAllStringSubmatch(textToCheck, -1)
-		//return len(match) > 0
-		if (lastChar >= rune(47) && lastChar <= rune(58)) || lastChar == rune(32) {
-			return true
-		}
-		return false
+	card.DueDate = deck_date.FormatApi(card.DueDate)
 
-	}, func(date string) {
+	addDueDateField(addForm, card.DueDate, func(date string) {
 		card.DueDate = date
 	})
 
@@ -561,6 +1347,17 @@ func BuildDetailForm(card *deck_structs.Card) (*tview.Form, *deck_structs.Card)
 	return addForm, card
 }
 
+// addDueDateField adds the due date input and a live preview of the resolved timestamp
+func addDueDateField(form *tview.Form, value string, changed func(date string)) {
+	form.AddInputField("Due Date", value, 30, nil, nil)
+	form.AddTextView("", deck_date.Describe(value), 40, 1, true, false)
+	preview := form.GetFormItem(form.GetFormItemCount() - 1).(*tview.TextView)
+	form.GetFormItemByLabel("Due Date").(*tview.InputField).SetChangedFunc(func(date string) {
+		preview.SetText(deck_date.Describe(date))
+		changed(date)
+	})
+}
+
 func AddCard(actualList *tview.List, card deck_structs.Card) {
 	var stackIndex, stack, _ = deck_stack.GetActualStack(actualList)
 
@@ -573,49 +1370,247 @@ func AddCard(actualList *tview.List, card deck_structs.Card) {
 		return
 	}
 
-	dueDate := ""
-	if len(card.DueDate) > 0 {
-		parse, _ := time.Parse("2006-01-02T15:04:05+00:00", newCard.DueDate)
-		newCard.DueDate = parse.Format("02/01/2006 15:04")
-		dueDate = fmt.Sprintf("(%s)", newCard.DueDate)
-	}
-
-	actualList.InsertItem(card.Order, fmt.Sprintf("[%s]#%d[white] - %s [red:-:-]%s[white]", configuration.Color, newCard.Id, newCard.Title, dueDate), "", rune(0), nil)
+	actualList.InsertItem(card.Order, buildCardItem(newCard), "", rune(0), nil)
 	CardsMap[newCard.Id] = newCard
 	DetailText.Clear()
-	EditableCard = newCard
 	if deck_stack.Stacks[stackIndex].Cards == nil || len(deck_stack.Stacks[stackIndex].Cards) == 0 {
 		deck_stack.Stacks[stackIndex].Cards = append(deck_stack.Stacks[stackIndex].Cards, newCard)
 	} else {
 		deck_stack.Stacks[stackIndex].Cards = append(deck_stack.Stacks[stackIndex].Cards[:1], deck_stack.Stacks[stackIndex].Cards[0:]...)
 		deck_stack.Stacks[stackIndex].Cards[0] = newCard
 	}
-	DetailText.SetTitle(fmt.Sprintf(" %s ", newCard.Title))
-	DetailText.SetText(utils.FormatDescription(newCard.Description))
-	deck_ui.BuildFullFlex(DetailText, err)
+	actualList.SetTitle(buildStackTitle(deck_stack.Stacks[stackIndex]))
+	ShowCard(newCard)
+}
+
+// editCard saves the card, unless it has been changed on the server in a way that conflicts with our changes,
+// saved is shown in the footer once the card is saved
+func editCard(saved string) {
+	saveMutex.Lock()
+	defer saveMutex.Unlock()
+
+	card := EditableCard
+	base := loadedCard
+	if lastSaved.Id == card.Id && base.Id == card.Id && lastSaved.LastModified >= base.LastModified {
+		base = lastSaved
+	}
+	remote, err := deck_http.GetCard(currentBoard.Id, card.StackId, card.Id, configuration)
+	if err == nil && base.Id == card.Id && changedOnServer(base, remote) {
+		merged, conflict := mergeRemote(base, card, remote)
+		if conflict {
+			app.QueueUpdateDraw(func() {
+				showConflict(base, card, remote)
+			})
+			return
+		}
+		if merged.Title != card.Title || merged.Description != card.Description || merged.DueDate != card.DueDate {
+			app.QueueUpdateDraw(func() {
+				adoptRemote(merged)
+			})
+		}
+		card = merged
+	}
+	if updated, ok := saveCard(card, saved); ok {
+		lastSaved = updated
+	}
 }
 
-func editCard() {
-	description := utils.CleanText(EditableCard.Description)
-	title := utils.CleanText(EditableCard.Title)
+// saveCard sends the card to the server showing saved in the footer, ok reports if it has been saved
+func saveCard(card deck_structs.Card, saved string) (deck_structs.Card, bool) {
 	dueDateFormat := ""
-	if len(EditableCard.DueDate) > 0 {
-		dueDateFormat = fmt.Sprintf(`,"duedate": "%s"`, EditableCard.DueDate)
+	if len(card.DueDate) > 0 {
+		dueDateFormat = fmt.Sprintf(`,"duedate": "%s"`, card.DueDate)
 	}
-	jsonBody := fmt.Sprintf(`{"description": "%s", "title": "%s", "type": "plain", "owner":"%s"%s}`, utils.CleanText(description), utils.CleanText(title), configuration.User, dueDateFormat)
-	var err error
-	_, err = deck_http.UpdateCard(currentBoard.Id, EditableCard.StackId, EditableCard.Id, jsonBody, configuration)
+	jsonBody := fmt.Sprintf(`{"description": "%s", "title": "%s", "type": "plain", "owner":"%s"%s}`, utils.CleanText(card.Description), utils.CleanText(card.Title), configuration.User, dueDateFormat)
+	updated, err := deck_http.UpdateCard(currentBoard.Id, card.StackId, card.Id, jsonBody, configuration)
 	if err != nil {
-		deck_ui.FooterBar.SetText(fmt.Sprintf("Error updating card: %s", err.Error()))
+		app.QueueUpdateDraw(func() {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error updating card: %s", err.Error()))
+		})
+		return updated, false
+	}
+	app.QueueUpdateDraw(func() {
+		refreshCard(updated)
+		if len(saved) > 0 {
+			deck_ui.FooterBar.SetText(saved)
+		}
+	})
+	return updated, true
+}
+
+// changedOnServer reports if the card on the server is not the version loaded as base
+func changedOnServer(base deck_structs.Card, remote deck_structs.Card) bool {
+	if len(base.ETag) > 0 && len(remote.ETag) > 0 {
+		return base.ETag != remote.ETag
+	}
+	return base.LastModified != remote.LastModified
+}
+
+// mergeRemote takes the fields changed only on the server into ours,
+// conflict reports if a field has been changed in different ways on both sides
+func mergeRemote(base deck_structs.Card, ours deck_structs.Card, remote deck_structs.Card) (deck_structs.Card, bool) {
+	conflict := false
+	merge := func(baseValue string, ourValue string, remoteValue string) string {
+		if remoteValue == baseValue || remoteValue == ourValue {
+			return ourValue
+		}
+		if ourValue == baseValue {
+			return remoteValue
+		}
+		conflict = true
+		return ourValue
+	}
+	ours.Title = merge(base.Title, ours.Title, remote.Title)
+	ours.Description = merge(base.Description, ours.Description, remote.Description)
+	ours.DueDate = merge(base.DueDate, ours.DueDate, remote.DueDate)
+	return ours, conflict
+}
+
+// adoptRemote shows the changes made on the server merged into the card being saved
+func adoptRemote(merged deck_structs.Card) {
+	if EditableCard.Id != merged.Id {
+		return
 	}
+	EditableCard.Title = merged.Title
+	EditableCard.Description = merged.Description
+	EditableCard.DueDate = merged.DueDate
+	UpdateLocalCard(EditableCard)
+	BuildStacks()
+	DetailText.SetText(renderDetail(EditableCard))
+	refreshHeader()
+	deck_ui.FooterBar.SetText("Card changed on the server, the changes have been merged with yours")
+}
+
+// showConflict shows the original, our and their version of a card changed on both sides,
+// to overwrite their changes, discard ours or merge them in the editor
+func showConflict(base deck_structs.Card, ours deck_structs.Card, theirs deck_structs.Card) {
+	versions := tview.NewFlex()
+	versions.SetDirection(tview.FlexColumn)
+	for _, v := range []struct {
+		title string
+		card  deck_structs.Card
+	}{{" Original ", base}, {" Yours ", ours}, {" Theirs (server) ", theirs}} {
+		view := tview.NewTextView()
+		view.SetBorder(true)
+		view.SetBorderColor(utils.GetColor(configuration.Color))
+		view.SetTitle(v.title)
+		view.SetDynamicColors(true)
+		view.SetWordWrap(true)
+		view.SetText(fmt.Sprintf("[%s::b]Title:[-::-] %s\n[%s::b]Due:[-::-] %s\n\n%s",
+			configuration.Color, tview.Escape(v.card.Title), configuration.Color, deck_date.FormatApi(v.card.DueDate),
+			tview.Escape(utils.FormatDescription(v.card.Description))))
+		versions.AddItem(view, 0, 1, false)
+	}
+
+	form := tview.NewForm()
+	form.SetButtonBackgroundColor(utils.GetColor(configuration.Color))
+	form.AddButton("Overwrite", func() {
+		// save ours over their version
+		loadedCard = theirs
+		EditableCard = ours
+		go editCard("")
+		showTab(descriptionTab, nil)
+	})
+	form.AddButton("Discard", func() {
+		// take their version, losing ours
+		loadedCard = theirs
+		EditableCard.Title = theirs.Title
+		EditableCard.Description = theirs.Description
+		EditableCard.DueDate = theirs.DueDate
+		EditableCard.LastModified = theirs.LastModified
+		EditableCard.ETag = theirs.ETag
+		UpdateLocalCard(EditableCard)
+		BuildStacks()
+		DetailText.SetText(renderDetail(EditableCard))
+		showTab(descriptionTab, nil)
+		deck_ui.FooterBar.SetText("Your changes have been discarded")
+	})
+	form.AddButton("Merge in editor", func() {
+		merged, _ := mergeRemote(base, ours, theirs)
+		text, _ := deck_merge.Merge(utils.FormatDescription(base.Description), utils.FormatDescription(ours.Description),
+			utils.FormatDescription(theirs.Description))
+		description, _, err := deck_ui.OpenEditor(text)
+		if err != nil {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error running editor: %s", err.Error()))
+			return
+		}
+		if deck_merge.HasMarkers(description) {
+			deck_ui.FooterBar.SetText("The description still has conflict markers, merge again or choose another option")
+			return
+		}
+		merged.Description = description
+		loadedCard = theirs
+		EditableCard = merged
+		go editCard("")
+		DetailText.SetText(renderDetail(EditableCard))
+		showTab(descriptionTab, nil)
+	})
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			deck_ui.FooterBar.SetText("Choose [yellow]Overwrite[white], [yellow]Discard[white] or [yellow]Merge in editor[white]")
+			return nil
+		}
+		return event
+	})
+
+	conflictFlex := tview.NewFlex()
+	conflictFlex.SetDirection(tview.FlexRow)
+	conflictFlex.SetBorder(true)
+	conflictFlex.SetBorderColor(utils.GetColor(configuration.Color))
+	conflictFlex.SetTitle(fmt.Sprintf(" #%d - %s - EDIT CONFLICT ", ours.Id, ours.Title))
+	conflictFlex.AddItem(versions, 0, 1, false)
+	conflictFlex.AddItem(form, 3, 0, true)
+	deck_ui.BuildFullFlex(conflictFlex, nil)
+	deck_ui.FooterBar.SetText("The card has been changed on the server while you were editing it")
+}
+
+// UpdateCard saves a card of any board in background, keeping the local copies up to date with the server
+func UpdateCard(boardId int, card deck_structs.Card) {
+	go updateCard(boardId, card.StackId, card.Id, cardUpdateJson(card))
 }
 
 func updateCard(boardId, stackId int, cardId int, jsonBody string) {
-	_, err := deck_http.UpdateCard(boardId, stackId, cardId, jsonBody, configuration)
+	updated, err := deck_http.UpdateCard(boardId, stackId, cardId, jsonBody, configuration)
 	if err != nil {
-		deck_ui.FooterBar.SetText(fmt.Sprintf("Error moving card: %s", err.Error()))
+		app.QueueUpdateDraw(func() {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error updating card: %s", err.Error()))
+		})
+		return
+	}
+	app.QueueUpdateDraw(func() {
+		refreshCard(updated)
+	})
+}
+
+// refreshCard copies the fields set by the server on update into the local copies of the card and stores the stacks
+func refreshCard(updated deck_structs.Card) {
+	if updated.Id == 0 {
 		return
 	}
+	refresh := func(card *deck_structs.Card) {
+		card.LastModified = updated.LastModified
+		card.ETag = updated.ETag
+	}
+	if card, ok := CardsMap[updated.Id]; ok {
+		refresh(&card)
+		CardsMap[updated.Id] = card
+	}
+	for i, s := range deck_stack.Stacks {
+		for j, c := range s.Cards {
+			if c.Id == updated.Id {
+				refresh(&deck_stack.Stacks[i].Cards[j])
+			}
+		}
+	}
+	if EditableCard.Id == updated.Id {
+		refresh(&EditableCard)
+		loadedCard = updated
+		refreshHeader()
+	}
+	err := deck_db.SaveStacks(currentBoard.Id, deck_stack.Stacks, configuration)
+	if err != nil {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Error saving stacks: %s", err.Error()))
+	}
 }
 
 func DeleteCard(cardId int, stack deck_structs.Stack, actualList *tview.List, currentItemIndex int) {
@@ -645,6 +1640,16 @@ func DeleteCard(cardId int, stack deck_structs.Stack, actualList *tview.List, cu
 				}
 			}()
 			actualList.RemoveItem(currentItemIndex)
+			delete(CardsMap, cardId)
+			if stackIndex, _, err := deck_stack.GetActualStack(actualList); err == nil {
+				for j, c := range deck_stack.Stacks[stackIndex].Cards {
+					if c.Id == cardId {
+						deck_stack.Stacks[stackIndex].Cards = append(deck_stack.Stacks[stackIndex].Cards[:j], deck_stack.Stacks[stackIndex].Cards[j+1:]...)
+						break
+					}
+				}
+				actualList.SetTitle(buildStackTitle(deck_stack.Stacks[stackIndex]))
+			}
 			deck_ui.MainFlex.RemoveItem(Modal)
 			app.SetFocus(actualList)
 		} else if buttonLabel == "No" {
@@ -657,6 +1662,264 @@ func DeleteCard(cardId int, stack deck_structs.Stack, actualList *tview.List, cu
 	app.SetFocus(Modal)
 }
 
+// FocusCard focuses the list of a stack in the main view, selecting the card if listed or the item at index
+func FocusCard(stackId int, cardId int, index int) {
+	for i, s := range deck_stack.Stacks {
+		if s.Id != stackId {
+			continue
+		}
+		list, ok := deck_ui.PrimitivesIndexMap[i].(*tview.List)
+		if !ok {
+			return
+		}
+		for j := 0; j < list.GetItemCount(); j++ {
+			if text, _ := list.GetItemText(j); utils.GetId(text) == cardId {
+				index = j
+				break
+			}
+		}
+		if index >= list.GetItemCount() {
+			index = list.GetItemCount() - 1
+		}
+		list.SetCurrentItem(index)
+		app.SetFocus(list)
+		return
+	}
+}
+
+// cardUpdateJson returns the body to update a card with all the fields the update replaces
+func cardUpdateJson(card deck_structs.Card) string {
+	doneJson := "null"
+	if len(card.Done) > 0 {
+		doneJson = fmt.Sprintf(`"%s"`, card.Done)
+	}
+	dueDateFormat := ""
+	if len(card.DueDate) > 0 {
+		dueDateFormat = fmt.Sprintf(`,"duedate": "%s"`, card.DueDate)
+	}
+	return fmt.Sprintf(`{"stackId": %d, "title": "%s", "description": "%s", "type": "plain", "owner":"%s", "done": %s%s}`,
+		card.StackId, utils.CleanText(card.Title), utils.CleanText(card.Description), configuration.User, doneJson, dueDateFormat)
+}
+
+// MoveCard moves a card to the top of a stack of the current board or, with labels matched by title, of another board
+func MoveCard(card deck_structs.Card, board deck_structs.Board, stack deck_structs.Stack) {
+	fromStackId := card.StackId
+	card.StackId = stack.Id
+
+	if board.Id == currentBoard.Id {
+		go func() {
+			updateCard(currentBoard.Id, fromStackId, card.Id, cardUpdateJson(card))
+			reorderCard(currentBoard.Id, stack.Id, card.Id, 0)
+		}()
+		for i, s := range deck_stack.Stacks {
+			cards := make([]deck_structs.Card, 0)
+			if s.Id == stack.Id {
+				cards = append(cards, card)
+			}
+			for _, c := range s.Cards {
+				if c.Id != card.Id {
+					cards = append(cards, c)
+				}
+			}
+			if s.Id == stack.Id {
+				for j := range cards {
+					cards[j].Order = j
+					CardsMap[cards[j].Id] = cards[j]
+				}
+			}
+			deck_stack.Stacks[i].Cards = cards
+		}
+		BuildStacks()
+		FocusCard(stack.Id, card.Id, 0)
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d moved to %s", card.Id, stack.Title))
+		return
+	}
+
+	fromBoardId := currentBoard.Id
+	go func() {
+		_, err := deck_http.UpdateCard(fromBoardId, fromStackId, card.Id, cardUpdateJson(card), configuration)
+		if err != nil {
+			app.QueueUpdateDraw(func() {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error moving card: %s", err.Error()))
+			})
+			return
+		}
+		missing, errs := remapLabels(card, board.Id, stack.Id)
+		app.QueueUpdateDraw(func() {
+			text := fmt.Sprintf("Card #%d moved to %s / %s", card.Id, board.Title, stack.Title)
+			if len(missing) > 0 {
+				text = fmt.Sprintf("%s, labels not found on the board: %s", text, strings.Join(missing, ", "))
+			}
+			if len(errs) > 0 {
+				text = fmt.Sprintf("%s, error updating labels: %s", text, strings.Join(errs, "; "))
+			}
+			deck_ui.FooterBar.SetText(text)
+		})
+	}()
+
+	for i, s := range deck_stack.Stacks {
+		for j, c := range s.Cards {
+			if c.Id == card.Id {
+				deck_stack.Stacks[i].Cards = append(s.Cards[:j], s.Cards[j+1:]...)
+				break
+			}
+		}
+	}
+	delete(CardsMap, card.Id)
+	BuildStacks()
+	FocusCard(fromStackId, card.Id, 0)
+	err := deck_db.SaveStacks(currentBoard.Id, deck_stack.Stacks, configuration)
+	if err != nil {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Error saving stacks: %s", err.Error()))
+	}
+}
+
+// remapLabels replaces the labels of a card moved to another board with the labels of that board with the same title,
+// returning the titles without a match and the errors of the label updates
+func remapLabels(card deck_structs.Card, boardId int, stackId int) ([]string, []string) {
+	missing := make([]string, 0)
+	errs := make([]string, 0)
+	if len(card.Labels) == 0 {
+		return missing, errs
+	}
+	board, err := deck_db.GetBoardDetails(boardId, true, configuration)
+	if err != nil {
+		return missing, append(errs, fmt.Sprintf("getting the labels of the board: %s", err.Error()))
+	}
+	for _, l := range card.Labels {
+		_, err = deck_http.DeleteLabel(boardId, stackId, card.Id, fmt.Sprintf(`{"labelId": %d}`, l.Id), configuration)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("removing %s: %s", l.Title, err.Error()))
+		}
+		found := false
+		for _, bl := range board.Labels {
+			if strings.EqualFold(strings.TrimSpace(bl.Title), strings.TrimSpace(l.Title)) {
+				found = true
+				_, err = deck_http.AssignLabel(boardId, stackId, card.Id, fmt.Sprintf(`{"labelId": %d}`, bl.Id), configuration)
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("assigning %s: %s", bl.Title, err.Error()))
+				}
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, l.Title)
+		}
+	}
+	return missing, errs
+}
+
+// SetCardDone marks a card done or not done, moving it to the configured doneStack when done
+func SetCardDone(card deck_structs.Card, done bool) {
+	stackId := card.StackId
+	card.Done = ""
+	if done {
+		card.Done = deck_date.ToApi(time.Now())
+		for _, s := range deck_stack.Stacks {
+			if len(configuration.DoneStack) > 0 && strings.EqualFold(s.Title, configuration.DoneStack) {
+				card.StackId = s.Id
+				break
+			}
+		}
+	}
+	go updateCard(currentBoard.Id, stackId, card.Id, cardUpdateJson(card))
+
+	for i, s := range deck_stack.Stacks {
+		cards := make([]deck_structs.Card, 0)
+		for _, c := range s.Cards {
+			if c.Id != card.Id {
+				cards = append(cards, c)
+			}
+		}
+		if s.Id == card.StackId {
+			cards = append(cards, card)
+		}
+		deck_stack.Stacks[i].Cards = cards
+	}
+	CardsMap[card.Id] = card
+	if EditableCard.Id == card.Id {
+		EditableCard.Done = card.Done
+		EditableCard.StackId = card.StackId
+		refreshHeader()
+	}
+	BuildStacks()
+	if done {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d done", card.Id))
+	} else {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d not done", card.Id))
+	}
+}
+
+// ArchiveCard archives a card removing it from its stack, or unarchives it putting it back.
+// done, when not nil, is called once the server has archived / unarchived the card
+func ArchiveCard(card deck_structs.Card, archive bool, done func()) {
+	index := -1
+	for _, s := range deck_stack.Stacks {
+		if s.Id != card.StackId {
+			continue
+		}
+		for i, c := range s.Cards {
+			if c.Id == card.Id {
+				index = i
+				break
+			}
+		}
+	}
+
+	go func() {
+		updated, err := deck_http.ArchiveCard(currentBoard.Id, card.StackId, card.Id, archive, configuration)
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				// the server kept the card as it was, put it back where it was
+				setArchived(card, !archive, index)
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error archiving card: %s", err.Error()))
+				return
+			}
+			refreshCard(updated)
+			if done != nil {
+				done()
+			}
+		})
+	}()
+
+	setArchived(card, archive, -1)
+	if archive {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d archived, press [yellow]X[white] in the main view to see the archived cards", card.Id))
+	} else {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Card #%d unarchived", card.Id))
+	}
+}
+
+// setArchived updates the local copy of an archived / unarchived card, an unarchived card is put at index in its
+// stack, or at the bottom when index is -1
+func setArchived(card deck_structs.Card, archive bool, index int) {
+	card.Archived = archive
+	for i, s := range deck_stack.Stacks {
+		if s.Id != card.StackId {
+			continue
+		}
+		cards := make([]deck_structs.Card, 0)
+		for _, c := range s.Cards {
+			if c.Id != card.Id {
+				cards = append(cards, c)
+			}
+		}
+		if !archive {
+			if index < 0 || index > len(cards) {
+				index = len(cards)
+			}
+			cards = append(cards[:index], append([]deck_structs.Card{card}, cards[index:]...)...)
+		}
+		deck_stack.Stacks[i].Cards = cards
+	}
+	CardsMap[card.Id] = card
+	if EditableCard.Id == card.Id {
+		EditableCard.Archived = archive
+		refreshHeader()
+	}
+	BuildStacks()
+}
+
 func AssignLabel(jsonBody string) {
 	_, err := deck_http.AssignLabel(currentBoard.Id, EditableCard.StackId, EditableCard.Id, jsonBody, configuration)
 	if err != nil {
@@ -695,22 +1958,34 @@ func BuildStacks() {
 
 	for index, s := range deck_stack.Stacks {
 		todoList := tview.NewList()
-		todoList.SetTitle(fmt.Sprintf(" %s ", s.Title))
+		todoList.SetTitle(buildStackTitle(s))
 		todoList.SetBorder(true)
 
 		todoList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 			if event.Key() == tcell.KeyTAB {
 				return nil
 			}
+			if (event.Key() == tcell.KeyUp || event.Key() == tcell.KeyDown) && event.Modifiers()&tcell.ModShift != 0 {
+				// shift+up / shift+down -> move card up / down in the stack
+				if todoList.GetItemCount() == 0 || !CanEdit("reorder cards") {
+					return nil
+				}
+				if event.Key() == tcell.KeyUp {
+					moveCardInStack(todoList, deck_ui.Primitives[todoList], -1)
+				} else {
+					moveCardInStack(todoList, deck_ui.Primitives[todoList], 1)
+				}
+				return nil
+			}
 			if event.Key() == tcell.KeyRight {
-				if todoList.GetItemCount() == 0 {
+				if todoList.GetItemCount() == 0 || !CanEdit("move cards") {
 					return nil
 				}
 				moveStackModal(todoList, tcell.KeyRight)
 				return nil
 			}
 			if event.Key() == tcell.KeyLeft {
-				if todoList.GetItemCount() == 0 {
+				if todoList.GetItemCount() == 0 || !CanEdit("move cards") {
 					return nil
 				}
 				moveStackModal(todoList, tcell.KeyLeft)
@@ -726,37 +2001,15 @@ func BuildStacks() {
 		for _, card := range s.Cards {
 			var labels = utils.BuildLabels(card)
 			CardsMap[card.Id] = card
-
-			dueDate := ""
-			if len(card.DueDate) > 0 {
-				parse, _ := time.Parse("2006-01-02T15:04:05+00:00", card.DueDate)
-				card.DueDate = parse.Format("02/01/2006 15:04")
-				dueDate = fmt.Sprintf("- [red:-:-](%s)[white]", card.DueDate)
+			if HideDone && len(card.Done) > 0 {
+				continue
 			}
-
-			assigners := make([]string, 0)
-			for _, o := range card.AssignedUsers {
-				assigners = append(assigners, o.Participant.GetAbbrv())
-			}
-
-			assignersFormatter := ""
-			if len(assigners) > 0 {
-				assignersFormatter = fmt.Sprintf("- [red:gray:-]%s[-:-:-] ", utils.CommaString(assigners))
-			}
-
-			todoList.AddItem(fmt.Sprintf("[%s]#%d[white] %s- %s %s", configuration.Color, card.Id, assignersFormatter, card.Title, dueDate), labels, rune(0), nil)
+			todoList.AddItem(buildCardItem(card), labels, rune(0), nil)
 		}
 
 		todoList.SetSelectedFunc(func(index int, name string, secondName string, shortcut rune) {
 			cardId := utils.GetId(name)
-
-			DetailText.SetTitle(fmt.Sprintf(" #%d - %s ", CardsMap[cardId].Id, CardsMap[cardId].Title))
-			DetailText.SetDynamicColors(true)
-
-			description := utils.FormatDescription(CardsMap[cardId].Description)
-			DetailText.SetText(deck_markdown.GetMarkDownDescription(description, configuration))
-			EditableCard = CardsMap[cardId]
-			deck_ui.BuildFullFlex(DetailText, nil)
+			ShowCard(CardsMap[cardId])
 		})
 
 		todoList.SetFocusFunc(func() {
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
+	"tui-deck/deck_date"
+	"tui-deck/deck_db"
 	"tui-deck/deck_http"
 	"tui-deck/deck_markdown"
 	"tui-deck/deck_structs"
@@ -17,13 +20,42 @@ import (
 var Comments []deck_structs.Comment
 
 var CommentsMap = make(map[int]deck_structs.Comment)
-var CommentTree *tview.TreeView
+var CommentTree *tview.TextView
 var app *tview.Application
 var Modal *tview.Modal
 var configuration utils.Configuration
 
 var CommentTreeStructMap = make(map[int]*CommentStruct)
 
+const commentsPageSize = 20
+
+var commentsCardId int
+var commentsOffset int
+var hasMoreComments bool
+var loadingComments bool
+
+// Back shows the comments of the card again when a comment form is closed
+var Back func(err error)
+
+// Users are the users that can be mentioned in comments, card assignees first
+var Users []deck_structs.Owner
+
+// text before the cursor ending with an @mention being typed
+var mentionRe = regexp.MustCompile(`(?:^|[\s(\[{,;:])@([\p{L}\p{N}_\-.'@]*)$`)
+
+// comment view state: selected comment, collapsed threads, comments in display order
+var selectedComment int
+var collapsed = make(map[int]bool)
+var visibleComments []int
+var renderedWidth int
+
+// comments written after the last visit are marked as new
+var lastVisit time.Time
+
+// links of the rendered comments, numbered when showHints is set
+var links []string
+var showHints bool
+
 type CommentStruct struct {
 	Comment deck_structs.Comment
 	Parent  *CommentStruct
@@ -34,111 +66,305 @@ func Init(application *tview.Application, conf utils.Configuration) {
 	app = application
 	configuration = conf
 
-	CommentTree = tview.NewTreeView()
+	CommentTree = tview.NewTextView()
 	CommentTree.SetBorder(true)
 	CommentTree.SetBorderColor(utils.GetColor(configuration.Color))
+	CommentTree.SetDynamicColors(true)
+	CommentTree.SetRegions(true)
+	CommentTree.SetWrap(false)
+	CommentTree.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		// messages are wrapped when rendering, render again when the width changes
+		if width-2 != renderedWidth && width > 2 {
+			renderedWidth = width - 2
+			go app.QueueUpdateDraw(CreateCommentsTree)
+		}
+		return x + 1, y + 1, width - 2, height - 2
+	})
 
 	Modal = tview.NewModal()
 }
 
+// GetComments loads the newest page of the card comments, older pages are loaded scrolling to the end of the tree
 func GetComments(cardId int) {
-
+	commentsCardId = cardId
+	commentsOffset = 0
+	hasMoreComments = false
+	loadingComments = false
+	selectedComment = 0
+	collapsed = make(map[int]bool)
+	lastVisit = deck_db.GetLastVisit(cardId, configuration)
+	_ = deck_db.SetLastVisit(cardId, time.Now(), configuration)
+	Comments = make([]deck_structs.Comment, 0)
+	CommentsMap = make(map[int]deck_structs.Comment)
 	CommentTreeStructMap = make(map[int]*CommentStruct)
 
-	var err error
-	Comments, err = deck_http.GetComments(cardId, configuration)
+	page, err := deck_http.GetComments(cardId, commentsPageSize, commentsOffset, configuration)
 	if err != nil {
 		deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting comments from card: %s", err.Error()))
+		return
 	}
+	addPage(page)
+}
 
-	replies := make(map[int][]deck_structs.Comment)
-	for _, c := range Comments {
-		CommentsMap[c.Id] = c
-		cs := CommentStruct{
-			Comment: c,
-		}
-		if c.ReplyTo != nil {
-			replies[c.ReplyTo.Id] = append(replies[c.ReplyTo.Id], c)
-		} else {
-			CommentTreeStructMap[c.Id] = &cs
+// addPage adds the next page of older comments, moving the offset of the following page
+func addPage(page []deck_structs.Comment) {
+	commentsOffset += len(page)
+	hasMoreComments = len(page) == commentsPageSize
+	addComments(page)
+}
+
+// addComments adds a page of comments to the reply threads, moving the replies
+// loaded before their parent under it
+func addComments(page []deck_structs.Comment) {
+	for _, c := range page {
+		// comments added while paging shift the offset, skip the ones already loaded
+		if _, ok := CommentsMap[c.Id]; ok {
+			continue
 		}
+		Comments = append(Comments, c)
+		CommentsMap[c.Id] = c
+		CommentTreeStructMap[c.Id] = &CommentStruct{Comment: c}
 	}
 
-	keySlice := make([]int, 0)
-	for key := range replies {
-		keySlice = append(keySlice, key)
+	for id, cs := range CommentTreeStructMap {
+		if cs.Comment.ReplyTo == nil {
+			continue
+		}
+		parent := findComment(cs.Comment.ReplyTo.Id)
+		if parent == nil || parent == cs {
+			continue
+		}
+		delete(CommentTreeStructMap, id)
+		cs.Parent = parent
+		parent.Replies = append(parent.Replies, cs)
+		sort.Slice(parent.Replies, func(i, j int) bool {
+			return parent.Replies[i].Comment.Id < parent.Replies[j].Comment.Id
+		})
 	}
-	sort.Ints(keySlice)
+}
 
-	for _, k := range keySlice {
-		r := replies[k]
-		cs, ok := CommentTreeStructMap[k]
-		if ok {
-			for _, r1 := range r {
-				c := CommentStruct{Comment: r1, Parent: cs}
-				cs.Replies = append(cs.Replies, &c)
+// loadOlderComments fetches the next page in background and adds it to the tree
+func loadOlderComments() {
+	loadingComments = true
+	deck_ui.FooterBar.SetText("Loading older comments...")
+	cardId := commentsCardId
+	offset := commentsOffset
+	go func() {
+		page, err := deck_http.GetComments(cardId, commentsPageSize, offset, configuration)
+		app.QueueUpdateDraw(func() {
+			if cardId != commentsCardId {
+				// another card has been opened meanwhile
+				return
 			}
-			CommentTreeStructMap[k] = cs
-		} else {
-			for k1, r1 := range CommentTreeStructMap {
-				for _, r2 := range r {
-					searchReplies(r1.Replies, k, r2)
-				}
-				CommentTreeStructMap[k1] = r1
+			loadingComments = false
+			if err != nil {
+				deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting comments from card: %s", err.Error()))
+				return
 			}
-		}
-	}
+			last := len(visibleComments)
+			addPage(page)
+			CreateCommentsTree()
+			// move to the first of the loaded comments
+			if last < len(visibleComments) {
+				selectComment(visibleComments[last])
+			}
+			deck_ui.FooterBar.SetText(fmt.Sprintf("%d comments loaded", len(CommentsMap)))
+		})
+	}()
 }
 
-func searchReplies(replies []*CommentStruct, k int, r deck_structs.Comment) {
-	for i, r2 := range replies {
-		if k == r2.Comment.Id {
-			c := CommentStruct{Comment: r, Parent: r2}
-			r2.Replies = append(r2.Replies, &c)
-			replies[i] = r2
-		} else {
-			searchReplies(r2.Replies, k, r)
-		}
+// GetSelectedId returns the id of the selected comment, 0 if no comment is selected
+func GetSelectedId() int {
+	return selectedComment
+}
+
+func selectComment(id int) {
+	selectedComment = id
+	if id == 0 {
+		CommentTree.Highlight()
+		return
 	}
+	CommentTree.Highlight(fmt.Sprintf("comment-%d", id)).ScrollToHighlight()
 }
 
+// CreateCommentsTree renders the comment threads, newest first, replies indented under their parent
 func CreateCommentsTree() {
-	root := tview.NewTreeNode("COMMENTS").
-		SetColor(utils.GetColor(configuration.Color)).SetSelectable(false)
-	CommentTree.SetRoot(root).SetCurrentNode(root)
+	_, _, width, _ := CommentTree.GetInnerRect()
+	if width <= 0 {
+		width = 80
+	}
+	renderedWidth = width
+	links = make([]string, 0)
+	visibleComments = make([]int, 0)
 
 	keySlice := make([]int, 0)
 	for key := range CommentTreeStructMap {
 		keySlice = append(keySlice, key)
 	}
-	sort.Ints(keySlice)
+	// newest first, older pages are appended at the end
+	sort.Sort(sort.Reverse(sort.IntSlice(keySlice)))
+	lines := make([]string, 0)
 	for _, key := range keySlice {
-		l := CommentTreeStructMap[key]
-		comment := l.Comment
-		node := tview.NewTreeNode(fmt.Sprintf("[%s:-:-]#%d[-:-:-] - [%s:-:i]%s - [%s][-:-:-] - %s", configuration.Color,
-			comment.Id, configuration.Color, comment.ActorDisplayName, getCreationDate(comment),
-			deck_markdown.GetMarkDownDescription(comment.Message, configuration)))
-		node.SetReference(l.Comment.Id)
-		buildTree(l.Replies, node)
-		root.AddChild(node)
-	}
-}
-
-func buildTree(replies []*CommentStruct, node *tview.TreeNode) {
-	if len(replies) > 0 {
-		for _, r := range replies {
-			node1 := tview.NewTreeNode(fmt.Sprintf("#%d - [-:-:i]%s - [%s][-:-:-] - %s", r.Comment.Id, r.Comment.ActorDisplayName,
-				getCreationDate(r.Comment), deck_markdown.GetMarkDownDescription(r.Comment.Message, configuration)))
-			node1.SetReference(r.Comment.Id)
-			node.AddChild(node1)
-			buildTree(r.Replies, node1)
+		lines = renderThread(CommentTreeStructMap[key], 0, width, lines)
+		lines = append(lines, "")
+	}
+	if len(keySlice) == 0 {
+		lines = append(lines, "[gray]No comments, press [yellow]a[gray] to add one[-]")
+	}
+	if hasMoreComments {
+		lines = append(lines, "[gray]▼ older comments, move down to load them[-]")
+	}
+	CommentTree.SetText(strings.Join(lines, "\n"))
+
+	visible := false
+	for _, id := range visibleComments {
+		visible = visible || id == selectedComment
+	}
+	if !visible {
+		selectedComment = 0
+		if len(visibleComments) > 0 {
+			selectedComment = visibleComments[0]
+		}
+	}
+	selectComment(selectedComment)
+}
+
+// renderThread renders a comment and, unless collapsed, its replies, wrapping the message to width
+func renderThread(cs *CommentStruct, depth int, width int, lines []string) []string {
+	comment := cs.Comment
+	visibleComments = append(visibleComments, comment.Id)
+	indent := strings.Repeat("│ ", depth)
+
+	marker := "  "
+	if len(cs.Replies) > 0 && collapsed[comment.Id] {
+		marker = "▸ "
+	} else if len(cs.Replies) > 0 {
+		marker = "▾ "
+	}
+	header := fmt.Sprintf(`%s%s["comment-%d"][%s::b]%s[-::-][""] [gray]#%d - %s[-]`, indent, marker, comment.Id,
+		configuration.Color, tview.Escape(comment.ActorDisplayName), comment.Id, getCreationDate(comment))
+	if isNew(comment) {
+		header += " [black:green:b] NEW [-:-:-]"
+	}
+	if len(cs.Replies) > 0 && collapsed[comment.Id] {
+		header += fmt.Sprintf(" [gray](%d hidden)[-]", countReplies(cs))
+	}
+	lines = append(lines, header)
+
+	bodyIndent := indent + "  "
+	if len(cs.Replies) > 0 && !collapsed[comment.Id] {
+		bodyIndent = indent + "│ "
+	}
+	bodyWidth := width - tview.TaggedStringWidth(bodyIndent)
+	if bodyWidth < 10 {
+		bodyWidth = 10
+	}
+	for _, line := range strings.Split(strings.TrimRight(renderMessage(comment), "\n"), "\n") {
+		for _, wrapped := range tview.WordWrap(line, bodyWidth) {
+			lines = append(lines, bodyIndent+wrapped)
+		}
+	}
+
+	if collapsed[comment.Id] {
+		return lines
+	}
+	for _, r := range cs.Replies {
+		lines = renderThread(r, depth+1, width, lines)
+	}
+	return lines
+}
+
+func countReplies(cs *CommentStruct) int {
+	count := len(cs.Replies)
+	for _, r := range cs.Replies {
+		count += countReplies(r)
+	}
+	return count
+}
+
+// isNew reports if a comment of somebody else has been written after the last visit to the card comments
+func isNew(comment deck_structs.Comment) bool {
+	if lastVisit.IsZero() || comment.ActorId == configuration.User {
+		return false
+	}
+	created, err := deck_date.FromApi(comment.CreationDateTime)
+	return err == nil && created.After(lastVisit)
+}
+
+// HandleKey moves the selection between comments and collapses / expands the replies
+func HandleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case event.Key() == tcell.KeyDown || event.Rune() == 'j':
+		moveSelection(1)
+	case event.Key() == tcell.KeyUp || event.Rune() == 'k':
+		moveSelection(-1)
+	case event.Key() == tcell.KeyEnter || event.Rune() == ' ':
+		setCollapsed(selectedComment, !collapsed[selectedComment])
+	case event.Key() == tcell.KeyLeft:
+		setCollapsed(selectedComment, true)
+	case event.Key() == tcell.KeyRight:
+		setCollapsed(selectedComment, false)
+	default:
+		return event
+	}
+	return nil
+}
+
+func moveSelection(offset int) {
+	index := -1
+	for i, id := range visibleComments {
+		if id == selectedComment {
+			index = i
+		}
+	}
+	index += offset
+	if index >= len(visibleComments) {
+		if hasMoreComments && !loadingComments {
+			loadOlderComments()
 		}
+		return
+	}
+	if index < 0 {
+		CommentTree.ScrollToBeginning()
+		return
+	}
+	selectComment(visibleComments[index])
+}
+
+func setCollapsed(id int, collapse bool) {
+	if node := findComment(id); node == nil || len(node.Replies) == 0 {
+		return
+	}
+	collapsed[id] = collapse
+	CreateCommentsTree()
+}
+
+// renderMessage renders a comment message, with link numbers when showHints is set
+func renderMessage(comment deck_structs.Comment) string {
+	rendered := deck_markdown.GetMarkDownComment(comment.Message, comment.Mentions, configuration, showHints, len(links))
+	if showHints {
+		links = append(links, deck_markdown.GetLinks(comment.Message)...)
 	}
+	return rendered
 }
 
+// ShowLinkHints rebuilds the tree with or without link numbers and returns the links in display order
+func ShowLinkHints(show bool) []string {
+	showHints = show
+	CreateCommentsTree()
+	if !show {
+		return nil
+	}
+	return links
+}
+
+// getCreationDate returns the relative and absolute creation time of a comment
 func getCreationDate(comment deck_structs.Comment) string {
-	parse, _ := time.Parse("2006-01-02T15:04:05+00:00", comment.CreationDateTime)
-	return parse.Format("15:04:05 - 2006-01-02")
+	created, err := deck_date.FromApi(comment.CreationDateTime)
+	if err != nil {
+		return comment.CreationDateTime
+	}
+	return fmt.Sprintf("%s · %s", deck_date.Ago(created, time.Now()), deck_date.Format(created))
 }
 
 func BuildAddForm(c deck_structs.Comment) (*tview.Form, *deck_structs.Comment) {
@@ -158,7 +384,7 @@ func BuildAddForm(c deck_structs.Comment) (*tview.Form, *deck_structs.Comment) {
 	addForm.SetLabelColor(utils.GetColor(configuration.Color))
 	addForm.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEsc {
-			deck_ui.BuildFullFlex(CommentTree, nil)
+			Back(nil)
 			return nil
 		}
 		return event
@@ -166,11 +392,109 @@ func BuildAddForm(c deck_structs.Comment) (*tview.Form, *deck_structs.Comment) {
 	addForm.AddTextArea("Message", c.Message, 60, 10, 300, func(message string) {
 		comment.Message = message
 	})
+	addMentionCompletion(addForm)
+	addForm.AddButton("Editor", func() {
+		// compose the message in $EDITOR
+		message, changed, err := deck_ui.OpenEditor(comment.Message)
+		if err != nil {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error running editor: %s", err.Error()))
+			return
+		}
+		if changed {
+			addForm.GetFormItemByLabel("Message").(*tview.TextArea).SetText(message, true)
+		}
+	})
 	return addForm, &comment
 }
 
+// addMentionCompletion shows the users matching the @mention typed in the message,
+// TAB inserts the selected one, ctrl+n / ctrl+p change the selection
+func addMentionCompletion(form *tview.Form) {
+	messageArea := form.GetFormItemByLabel("Message").(*tview.TextArea)
+	form.AddTextView("", "", 60, 1, true, false)
+	completion := form.GetFormItem(form.GetFormItemCount() - 1).(*tview.TextView)
+
+	suggestions := make([]deck_structs.Owner, 0)
+	selected := 0
+	start := 0
+	update := func() {
+		completion.SetText(formatSuggestions(suggestions, selected))
+	}
+	messageArea.SetMovedFunc(func() {
+		suggestions, start = findMentions(messageArea)
+		selected = 0
+		update()
+	})
+	messageArea.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if len(suggestions) == 0 {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyTab:
+			_, _, end := messageArea.GetSelection()
+			messageArea.Replace(start, end, FormatMention(suggestions[selected].Uid)+" ")
+			return nil
+		case tcell.KeyCtrlN:
+			selected = (selected + 1) % len(suggestions)
+			update()
+			return nil
+		case tcell.KeyCtrlP:
+			selected = (selected + len(suggestions) - 1) % len(suggestions)
+			update()
+			return nil
+		}
+		return event
+	})
+}
+
+// findMentions returns the users matching the @mention before the cursor and the position of the @
+func findMentions(messageArea *tview.TextArea) ([]deck_structs.Owner, int) {
+	_, cursor, end := messageArea.GetSelection()
+	if cursor != end {
+		return nil, 0
+	}
+	before := messageArea.GetText()[:cursor]
+	match := mentionRe.FindStringSubmatchIndex(before)
+	if match == nil {
+		return nil, 0
+	}
+	query := strings.ToLower(before[match[2]:match[3]])
+	found := make([]deck_structs.Owner, 0)
+	for _, u := range Users {
+		if strings.HasPrefix(strings.ToLower(u.Uid), query) || strings.Contains(strings.ToLower(u.DisplayName), query) {
+			found = append(found, u)
+		}
+	}
+	return found, match[2] - 1
+}
+
+func formatSuggestions(suggestions []deck_structs.Owner, selected int) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	items := make([]string, 0)
+	for i, u := range suggestions {
+		if i == selected {
+			items = append(items, fmt.Sprintf("[black:yellow]%s[-:-:-]", tview.Escape(u.DisplayName)))
+		} else {
+			items = append(items, fmt.Sprintf("[%s]%s[-]", configuration.Color, tview.Escape(u.DisplayName)))
+		}
+	}
+	return "TAB: " + strings.Join(items, " ")
+}
+
+// FormatMention returns the mention syntax nextcloud expects for uid, quoted when it contains spaces or symbols
+func FormatMention(uid string) string {
+	for _, r := range uid {
+		if !deck_markdown.IsMentionRune(r) {
+			return fmt.Sprintf(`@"%s"`, uid)
+		}
+	}
+	return "@" + uid
+}
+
 func AddComment(cardId int, comment deck_structs.Comment) error {
-	jsonBody := strings.ReplaceAll(fmt.Sprintf(`{"message":"%s" }`, comment.Message), "\n", `\n`)
+	jsonBody := fmt.Sprintf(`{"message":"%s" }`, utils.CleanText(comment.Message))
 	var newComment deck_structs.Comment
 	var err error
 	newComment, err = deck_http.AddComment(cardId, jsonBody, configuration)
@@ -180,41 +504,38 @@ func AddComment(cardId int, comment deck_structs.Comment) error {
 	}
 	CommentTreeStructMap[newComment.Id] = &CommentStruct{Comment: newComment}
 	CommentsMap[newComment.Id] = newComment
+	commentsOffset++
 	return nil
 }
 
 func EditComment(cardId int, comment deck_structs.Comment) error {
-	jsonBody := strings.ReplaceAll(fmt.Sprintf(`{"message":"%s" }`, comment.Message), "\n", `\n`)
+	jsonBody := fmt.Sprintf(`{"message":"%s" }`, utils.CleanText(comment.Message))
 	editComment, err := deck_http.EditComment(cardId, comment.Id, jsonBody, configuration)
 	if err != nil {
 		return err
 	} else {
-		for _, k := range CommentTreeStructMap {
-			node := findById(k, comment.Id)
-			if node != nil {
-				node.Comment = editComment
-				break
-			}
+		node := findComment(comment.Id)
+		if node != nil {
+			node.Comment = editComment
+			CommentsMap[editComment.Id] = editComment
 		}
 	}
 	return nil
 }
 
 func ReplyComment(cardId int, parentId int, comment deck_structs.Comment) error {
-	jsonBody := strings.ReplaceAll(fmt.Sprintf(`{"message":"%s", "parentId": %d }`, comment.Message, parentId), "\n", `\n`)
+	jsonBody := fmt.Sprintf(`{"message":"%s", "parentId": %d }`, utils.CleanText(comment.Message), parentId)
 	//var newComment deck_structs.Comment
 	newComment, err := deck_http.AddComment(cardId, jsonBody, configuration)
 	if err != nil {
 		deck_ui.FooterBar.SetText(fmt.Sprintf("Error replying comment: %s", err.Error()))
 		return err
 	} else {
-		for _, k := range CommentTreeStructMap {
-			node := findById(k, parentId)
-			if node != nil {
-				node.addReply(newComment)
-				CommentsMap[newComment.Id] = newComment
-				break
-			}
+		node := findComment(parentId)
+		if node != nil {
+			node.addReply(newComment)
+			CommentsMap[newComment.Id] = newComment
+			commentsOffset++
 		}
 	}
 	return nil
@@ -254,6 +575,11 @@ func DeleteComment(cardId int, commentId int) {
 
 					list := make([]*deck_structs.Comment, 0)
 					list = findReplies(node, list)
+					delete(CommentsMap, commentId)
+					for _, c := range list {
+						delete(CommentsMap, c.Id)
+					}
+					commentsOffset -= len(list) + 1
 
 					go func() {
 						for _, c := range list {
@@ -288,13 +614,21 @@ func findReplies(node *CommentStruct, list []*deck_structs.Comment) []*deck_stru
 
 	for _, r := range node.Replies {
 		list = append(list, &r.Comment)
-		if r.Replies != nil {
-			return findReplies(r, list)
-		}
+		list = findReplies(r, list)
 	}
 	return list
 }
 
+// findComment looks for a comment in all the reply threads
+func findComment(id int) *CommentStruct {
+	for _, root := range CommentTreeStructMap {
+		if node := findById(root, id); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
 func findById(root *CommentStruct, id int) *CommentStruct {
 	queue := make([]*CommentStruct, 0)
 	queue = append(queue, root)
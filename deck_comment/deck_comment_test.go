@@ -0,0 +1,25 @@
+package deck_comment
+
+import (
+	"testing"
+)
+
+func TestFormatMention(t *testing.T) {
+	tests := []struct {
+		uid  string
+		want string
+	}{
+		{"alice", "@alice"},
+		{"john.doe", "@john.doe"},
+		{"mary-jane", "@mary-jane"},
+		{"bob@example.com", "@bob@example.com"},
+		{"o'brien_2", "@o'brien_2"},
+		{"guest user", `@"guest user"`},
+		{"zoë", `@"zoë"`},
+	}
+	for _, test := range tests {
+		if got := FormatMention(test.uid); got != test.want {
+			t.Errorf("FormatMention(%q) = %q, want %q", test.uid, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package deck_date
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"tui-deck/deck_structs"
+	"tui-deck/utils"
+	"unicode"
+)
+
+const ApiLayout = "2006-01-02T15:04:05+00:00"
+const DefaultFormat = "dd/MM/yyyy HH:mm"
+
+// hour used when an expression does not specify a time (eod, tomorrow, fri...)
+const endOfDayHour = 17
+
+var displayLayout = toGoLayout(DefaultFormat)
+var dueSoonDays = 3
+
+type DueStatus int
+
+const (
+	DueNone DueStatus = iota
+	DueDone
+	DueOverdue
+	DueToday
+	DueSoon
+	DueFuture
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var isoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var relativeRe = regexp.MustCompile(`^(?:in\s+|\+)(\d+)\s*(minutes|minute|mins|min|m|hours|hour|h|days|day|d|weeks|week|w|months|month|mo)$`)
+var timeRe = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+
+// Init sets the display format and the due soon days, a dateFormat that can't be parsed back
+// to the same date (no year, 12 hours without am/pm...) is refused and the default format is kept
+func Init(conf utils.Configuration) error {
+	if conf.DueSoonDays > 0 {
+		dueSoonDays = conf.DueSoonDays
+	}
+	displayLayout = toGoLayout(DefaultFormat)
+	if len(conf.DateFormat) == 0 {
+		return nil
+	}
+	layout := toGoLayout(conf.DateFormat)
+	if !roundTrips(layout) {
+		return fmt.Errorf("dateFormat \"%s\" can't be read back without losing part of the date, using \"%s\"", conf.DateFormat, DefaultFormat)
+	}
+	displayLayout = layout
+	return nil
+}
+
+// dateTokens are the tokens of a dateFormat and their go layout
+var dateTokens = map[string]string{
+	"yyyy": "2006", "YYYY": "2006",
+	"yy": "06", "YY": "06",
+	"MMMM": "January", "MMM": "Jan", "MM": "01",
+	"dd": "02", "DD": "02",
+	"EEEE": "Monday", "EEE": "Mon",
+	"HH": "15", "hh": "03",
+	"mm": "04", "ss": "05",
+	"a": "PM",
+}
+
+// toGoLayout converts a dd/MM/yyyy HH:mm style pattern into a go time layout,
+// runs of letters that are not tokens, like the "a" of "at", are kept as they are
+func toGoLayout(format string) string {
+	runes := []rune(format)
+	var layout strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		run := string(runes[i:j])
+		token, ok := dateTokens[run]
+		if ok && run == "a" {
+			// am/pm only as a word of its own
+			ok = (i == 0 || !unicode.IsLetter(runes[i-1])) && (j == len(runes) || !unicode.IsLetter(runes[j]))
+		}
+		if ok {
+			layout.WriteString(token)
+		} else {
+			layout.WriteString(run)
+		}
+		i = j
+	}
+	return layout.String()
+}
+
+// roundTrips reports if a date formatted with layout is parsed back to the same minute
+func roundTrips(layout string) bool {
+	// day above 12 and afternoon hour, to catch swapped day and month and missing am/pm
+	reference := time.Date(2031, time.November, 23, 19, 45, 0, 0, time.Local)
+	parse, err := time.ParseInLocation(layout, reference.Format(layout), time.Local)
+	return err == nil && parse.Equal(reference)
+}
+
+// GetDisplayLayout returns the go layout used to show dates to the user
+func GetDisplayLayout() string {
+	return displayLayout
+}
+
+func Format(t time.Time) string {
+	return t.Local().Format(displayLayout)
+}
+
+func ToApi(t time.Time) string {
+	return t.UTC().Format(ApiLayout)
+}
+
+func FromApi(date string) (time.Time, error) {
+	return time.Parse(time.RFC3339, date)
+}
+
+// FormatApi formats a date returned by the deck api with the configured display format
+func FormatApi(date string) string {
+	if len(date) == 0 {
+		return ""
+	}
+	parse, err := FromApi(date)
+	if err != nil {
+		return date
+	}
+	return Format(parse)
+}
+
+// InputToApi resolves a user supplied due date expression to the deck api format
+func InputToApi(input string) (string, error) {
+	if len(strings.TrimSpace(input)) == 0 {
+		return "", nil
+	}
+	parse, err := Parse(input, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return ToApi(parse), nil
+}
+
+// UpdateToApi resolves an edited due date like InputToApi, an input left as shown for the original api date
+// keeps the original, as formats without seconds or time zone don't give back the same timestamp
+func UpdateToApi(input string, original string) (string, error) {
+	if len(original) > 0 && input == FormatApi(original) {
+		return original, nil
+	}
+	return InputToApi(input)
+}
+
+// Describe returns a short text for the live preview under due date fields
+func Describe(input string) string {
+	if len(strings.TrimSpace(input)) == 0 {
+		return "[gray]no due date[-]"
+	}
+	parse, err := Parse(input, time.Now())
+	if err != nil {
+		return fmt.Sprintf("[red]%s[-]", err.Error())
+	}
+	return fmt.Sprintf("[green]%s[-]", parse.Format("Mon "+displayLayout))
+}
+
+// Parse resolves expressions like "tomorrow", "fri 17:00", "next monday", "in 3 days",
+// "eod", ISO dates and dates in the configured display format
+func Parse(input string, now time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.Join(strings.Fields(input), " "))
+	now = now.Local()
+
+	if parse, err := time.ParseInLocation(displayLayout, strings.TrimSpace(input), time.Local); err == nil {
+		return parse, nil
+	}
+	for _, layout := range isoLayouts {
+		if parse, err := time.ParseInLocation(layout, strings.TrimSpace(input), time.Local); err == nil {
+			if layout == "2006-01-02" {
+				parse = parse.Add(endOfDayHour * time.Hour)
+			}
+			return parse, nil
+		}
+	}
+
+	if match := relativeRe.FindStringSubmatch(text); match != nil {
+		amount, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "minutes", "minute", "mins", "min", "m":
+			return now.Add(time.Duration(amount) * time.Minute), nil
+		case "hours", "hour", "h":
+			return now.Add(time.Duration(amount) * time.Hour), nil
+		case "days", "day", "d":
+			return now.AddDate(0, 0, amount), nil
+		case "weeks", "week", "w":
+			return now.AddDate(0, 0, 7*amount), nil
+		default:
+			return now.AddDate(0, amount, 0), nil
+		}
+	}
+
+	// split "<day> [at] <time>"
+	dayPart, timePart := text, ""
+	if i := strings.Index(text, " at "); i >= 0 {
+		dayPart, timePart = text[:i], text[i+4:]
+	} else if fields := strings.Fields(text); len(fields) > 1 && timeRe.MatchString(fields[len(fields)-1]) {
+		dayPart, timePart = strings.Join(fields[:len(fields)-1], " "), fields[len(fields)-1]
+	}
+
+	if timeRe.MatchString(text) {
+		dayPart, timePart = "today", text
+	}
+
+	day, hasTime, err := parseDay(dayPart, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(timePart) > 0 {
+		hour, minute, err := parseTime(timePart)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.Local), nil
+	}
+	if hasTime {
+		return day, nil
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), endOfDayHour, 0, 0, 0, time.Local), nil
+}
+
+// parseDay resolves the day part of an expression, hasTime reports if the result already carries a time
+func parseDay(text string, now time.Time) (time.Time, bool, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch text {
+	case "now":
+		return now, true, nil
+	case "today", "eod":
+		return today, false, nil
+	case "tomorrow", "tmr", "tom":
+		return today.AddDate(0, 0, 1), false, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), false, nil
+	case "eow":
+		return nextWeekday(today, time.Friday, false), false, nil
+	case "next week":
+		return today.AddDate(0, 0, 7), false, nil
+	case "next month":
+		return today.AddDate(0, 1, 0), false, nil
+	}
+
+	next := false
+	if strings.HasPrefix(text, "next ") {
+		next = true
+		text = strings.TrimPrefix(text, "next ")
+	}
+	if weekday, ok := weekdays[text]; ok {
+		return nextWeekday(today, weekday, next), false, nil
+	}
+
+	if parse, err := time.ParseInLocation("2006-01-02", text, time.Local); err == nil {
+		return parse, false, nil
+	}
+	if dateLayout := strings.TrimSpace(strings.Split(displayLayout, " ")[0]); len(dateLayout) > 0 {
+		if parse, err := time.ParseInLocation(dateLayout, text, time.Local); err == nil {
+			return parse, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("can't understand date \"%s\"", text)
+}
+
+// nextWeekday returns the first given weekday from today, today included unless strict is set
+func nextWeekday(today time.Time, weekday time.Weekday, strict bool) time.Time {
+	days := (int(weekday) - int(today.Weekday()) + 7) % 7
+	if days == 0 && strict {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+func parseTime(text string) (int, int, error) {
+	match := timeRe.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return 0, 0, fmt.Errorf("can't understand time \"%s\"", text)
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	if match[3] == "pm" && hour < 12 {
+		hour += 12
+	} else if match[3] == "am" && hour == 12 {
+		hour = 0
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("not a valid time \"%s\"", text)
+	}
+	return hour, minute, nil
+}
+
+func GetDueStatus(card deck_structs.Card, now time.Time) DueStatus {
+	if len(card.Done) > 0 {
+		return DueDone
+	}
+	if len(card.DueDate) == 0 {
+		return DueNone
+	}
+	due, err := FromApi(card.DueDate)
+	if err != nil {
+		return DueNone
+	}
+	due = due.Local()
+	now = now.Local()
+	if due.Before(now) {
+		return DueOverdue
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if due.Before(today.AddDate(0, 0, 1)) {
+		return DueToday
+	}
+	if due.Before(today.AddDate(0, 0, dueSoonDays+1)) {
+		return DueSoon
+	}
+	return DueFuture
+}
+
+func GetStatusColor(status DueStatus) string {
+	switch status {
+	case DueDone:
+		return "gray"
+	case DueOverdue:
+		return "red"
+	case DueToday:
+		return "orange"
+	case DueSoon:
+		return "yellow"
+	default:
+		return "green"
+	}
+}
+
+// Relative returns a short text like "in 2d", "3d late", "in 5h" or "today"
+func Relative(t time.Time, now time.Time) string {
+	diff := t.Sub(now)
+	late := diff < 0
+	if late {
+		diff = -diff
+	}
+	if diff < time.Minute {
+		return "now"
+	}
+	if late {
+		return fmt.Sprintf("%s late", shortDuration(diff))
+	}
+	return fmt.Sprintf("in %s", shortDuration(diff))
+}
+
+// Ago returns a short text like "2h ago" for a past time
+func Ago(t time.Time, now time.Time) string {
+	diff := now.Sub(t)
+	if diff < time.Minute {
+		return "just now"
+	}
+	return fmt.Sprintf("%s ago", shortDuration(diff))
+}
+
+func shortDuration(diff time.Duration) string {
+	switch {
+	case diff < time.Hour:
+		return fmt.Sprintf("%dm", int(diff.Minutes()))
+	case diff < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(diff.Hours()))
+	case diff < 60*24*time.Hour:
+		return fmt.Sprintf("%dd", int(diff.Hours()/24))
+	case diff < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(diff.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy", int(diff.Hours()/24/365))
+	}
+}
+
+// FormatDue returns the colored due date of a card for the stack lists, relative or absolute
+func FormatDue(card deck_structs.Card, relative bool) string {
+	if len(card.DueDate) == 0 {
+		return ""
+	}
+	due, err := FromApi(card.DueDate)
+	if err != nil {
+		return ""
+	}
+	text := Format(due)
+	if relative {
+		text = Relative(due, time.Now())
+	}
+	return fmt.Sprintf("[%s:-:-](%s)[-:-:-]", GetStatusColor(GetDueStatus(card, time.Now())), text)
+}
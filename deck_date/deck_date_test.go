@@ -0,0 +1,186 @@
+package deck_date
+
+import (
+	"testing"
+	"time"
+	"tui-deck/utils"
+)
+
+func TestToGoLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		layout string
+	}{
+		{"dd/MM/yyyy HH:mm", "02/01/2006 15:04"},
+		{"yyyy-MM-dd", "2006-01-02"},
+		{"EEE dd MMM yy", "Mon 02 Jan 06"},
+		{"EEEE, MMMM dd yyyy", "Monday, January 02 2006"},
+		{"MM/dd/yyyy hh:mm a", "01/02/2006 03:04 PM"},
+		{"MM/dd/yyyy hh:mm:ss a", "01/02/2006 03:04:05 PM"},
+		{"dd/MM/yyyy at HH:mm", "02/01/2006 at 15:04"},
+		{"yyyy-MM-dd (a)", "2006-01-02 (PM)"},
+	}
+	for _, test := range tests {
+		if layout := toGoLayout(test.format); layout != test.layout {
+			t.Errorf("toGoLayout(%q) = %q, want %q", test.format, layout, test.layout)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	defer func() {
+		_ = Init(utils.Configuration{})
+	}()
+	tests := []struct {
+		format string
+		valid  bool
+		layout string
+	}{
+		{"", true, "02/01/2006 15:04"},
+		{"dd/MM/yyyy HH:mm", true, "02/01/2006 15:04"},
+		{"MM/dd/yyyy hh:mm a", true, "01/02/2006 03:04 PM"},
+		{"EEE dd MMM yyyy HH:mm", true, "Mon 02 Jan 2006 15:04"},
+		{"dd/MM HH:mm", false, "02/01/2006 15:04"},
+		{"dd/MM/yyyy hh:mm", false, "02/01/2006 15:04"},
+		{"dd/MM/yyyy", false, "02/01/2006 15:04"},
+		{"MM/yyyy HH:mm", false, "02/01/2006 15:04"},
+	}
+	for _, test := range tests {
+		err := Init(utils.Configuration{DateFormat: test.format})
+		if (err == nil) != test.valid {
+			t.Errorf("Init(%q) error = %v, want valid %t", test.format, err, test.valid)
+		}
+		if layout := GetDisplayLayout(); layout != test.layout {
+			t.Errorf("Init(%q) layout = %q, want %q", test.format, layout, test.layout)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, time.October, 21, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"23/11/2026 19:45", time.Date(2026, time.November, 23, 19, 45, 0, 0, time.Local)},
+		{"2026-11-23", time.Date(2026, time.November, 23, 17, 0, 0, 0, time.Local)},
+		{"2026-11-23 08:15", time.Date(2026, time.November, 23, 8, 15, 0, 0, time.Local)},
+		{"now", now},
+		{"today", time.Date(2026, time.October, 21, 17, 0, 0, 0, time.Local)},
+		{"eod", time.Date(2026, time.October, 21, 17, 0, 0, 0, time.Local)},
+		{"tomorrow", time.Date(2026, time.October, 22, 17, 0, 0, 0, time.Local)},
+		{"Tomorrow at 9am", time.Date(2026, time.October, 22, 9, 0, 0, 0, time.Local)},
+		{"yesterday", time.Date(2026, time.October, 20, 17, 0, 0, 0, time.Local)},
+		{"eow", time.Date(2026, time.October, 23, 17, 0, 0, 0, time.Local)},
+		{"wed", time.Date(2026, time.October, 21, 17, 0, 0, 0, time.Local)},
+		{"next wednesday", time.Date(2026, time.October, 28, 17, 0, 0, 0, time.Local)},
+		{"fri 17:30", time.Date(2026, time.October, 23, 17, 30, 0, 0, time.Local)},
+		{"monday at 12am", time.Date(2026, time.October, 26, 0, 0, 0, 0, time.Local)},
+		{"14:00", time.Date(2026, time.October, 21, 14, 0, 0, 0, time.Local)},
+		{"in 3 days", time.Date(2026, time.October, 24, 10, 30, 0, 0, time.Local)},
+		{"in 2h", time.Date(2026, time.October, 21, 12, 30, 0, 0, time.Local)},
+		{"+1w", time.Date(2026, time.October, 28, 10, 30, 0, 0, time.Local)},
+		{"next week", time.Date(2026, time.October, 28, 17, 0, 0, 0, time.Local)},
+		{"next month", time.Date(2026, time.November, 21, 17, 0, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		parse, err := Parse(test.input, now)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", test.input, err)
+			continue
+		}
+		if !parse.Equal(test.want) {
+			t.Errorf("Parse(%q) = %v, want %v", test.input, parse, test.want)
+		}
+	}
+
+	for _, input := range []string{"someday", "fri 25:00", "in three days", "32/01/2026 10:00"} {
+		if parse, err := Parse(input, now); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", input, parse)
+		}
+	}
+}
+
+func TestInputToApi(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"2026-11-23T19:45:00Z", "2026-11-23T19:45:00+00:00"},
+		{"2026-11-23T21:45:00+02:00", "2026-11-23T19:45:00+00:00"},
+		{Format(time.Date(2026, time.November, 23, 19, 45, 0, 0, time.Local)),
+			ToApi(time.Date(2026, time.November, 23, 19, 45, 0, 0, time.Local))},
+	}
+	for _, test := range tests {
+		api, err := InputToApi(test.input)
+		if err != nil {
+			t.Errorf("InputToApi(%q) error: %v", test.input, err)
+			continue
+		}
+		if api != test.want {
+			t.Errorf("InputToApi(%q) = %q, want %q", test.input, api, test.want)
+		}
+	}
+	if _, err := InputToApi("someday"); err == nil {
+		t.Errorf("InputToApi(\"someday\") want an error")
+	}
+}
+
+func TestUpdateToApi(t *testing.T) {
+	defer func() {
+		_ = Init(utils.Configuration{})
+	}()
+	// seconds are not shown by the formats
+	original := "2026-11-23T19:45:30+00:00"
+	tests := []struct {
+		format    string
+		untouched bool
+		input     string
+		want      string
+	}{
+		{"", true, "", original},
+		{"MM/dd/yyyy hh:mm a", true, "", original},
+		{"", false, "2026-11-24T08:00:00Z", "2026-11-24T08:00:00+00:00"},
+		{"", false, "", ""},
+	}
+	for _, test := range tests {
+		if err := Init(utils.Configuration{DateFormat: test.format}); err != nil {
+			t.Fatalf("Init(%q) error: %v", test.format, err)
+		}
+		input := test.input
+		if test.untouched {
+			input = FormatApi(original)
+		}
+		api, err := UpdateToApi(input, original)
+		if err != nil {
+			t.Errorf("UpdateToApi(%q) error: %v", input, err)
+			continue
+		}
+		if api != test.want {
+			t.Errorf("UpdateToApi(%q) = %q, want %q", input, api, test.want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	defer func() {
+		_ = Init(utils.Configuration{})
+	}()
+	date := time.Date(2026, time.March, 5, 7, 9, 0, 0, time.Local)
+	for _, format := range []string{"dd/MM/yyyy HH:mm", "MM/dd/yy hh:mm a", "EEEE dd MMMM yyyy HH:mm", "yyyy-MM-dd HH:mm:ss"} {
+		if err := Init(utils.Configuration{DateFormat: format}); err != nil {
+			t.Fatalf("Init(%q) error: %v", format, err)
+		}
+		parse, err := Parse(Format(date), time.Now())
+		if err != nil {
+			t.Errorf("%q: Parse(%q) error: %v", format, Format(date), err)
+			continue
+		}
+		if !parse.Equal(date) {
+			t.Errorf("%q: Parse(%q) = %v, want %v", format, Format(date), parse, date)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 	"tui-deck/deck_http"
 	"tui-deck/deck_structs"
 	"tui-deck/utils"
@@ -63,17 +65,7 @@ func GetStacks(boardId int, updateBoard bool, configuration utils.Configuration)
 		if err != nil {
 			return nil, err
 		}
-		var stacksFile *os.File
-		stacksFile, err = utils.CreateFile(fileName)
-		if err != nil {
-			return nil, err
-		}
-		var marshal []byte
-		marshal, err = json.Marshal(stacks)
-		if err != nil {
-			return nil, err
-		}
-		_, err = stacksFile.Write(marshal)
+		err = SaveStacks(boardId, stacks, configuration)
 		if err != nil {
 			return nil, err
 		}
@@ -91,3 +83,85 @@ func GetStacks(boardId int, updateBoard bool, configuration utils.Configuration)
 	}
 	return stacks, nil
 }
+
+// SaveStacks stores the stacks of a board, so local changes survive until the board etag changes
+func SaveStacks(boardId int, stacks []deck_structs.Stack, configuration utils.Configuration) error {
+	stacksFile, err := utils.CreateFile(fmt.Sprintf("%s/db/stacks-%d.json", configuration.ConfigDir, boardId))
+	if err != nil {
+		return err
+	}
+	defer stacksFile.Close()
+	marshal, err := json.Marshal(stacks)
+	if err != nil {
+		return err
+	}
+	_, err = stacksFile.Write(marshal)
+	return err
+}
+
+// FindCard looks for a card in the cached stacks of all boards, returning the card, its board id and its stack
+func FindCard(cardId int, configuration utils.Configuration) (deck_structs.Card, int, deck_structs.Stack, bool) {
+	files, err := filepath.Glob(fmt.Sprintf("%s/db/stacks-*.json", configuration.ConfigDir))
+	if err != nil {
+		return deck_structs.Card{}, 0, deck_structs.Stack{}, false
+	}
+	for _, fileName := range files {
+		var boardId int
+		_, err = fmt.Sscanf(filepath.Base(fileName), "stacks-%d.json", &boardId)
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			continue
+		}
+		stacks := make([]deck_structs.Stack, 0)
+		if json.Unmarshal(content, &stacks) != nil {
+			continue
+		}
+		for _, s := range stacks {
+			for _, c := range s.Cards {
+				if c.Id == cardId {
+					return c, boardId, s, true
+				}
+			}
+		}
+	}
+	return deck_structs.Card{}, 0, deck_structs.Stack{}, false
+}
+
+// GetLastVisit returns when the comments of a card have been viewed the last time, zero if never
+func GetLastVisit(cardId int, configuration utils.Configuration) time.Time {
+	visits := getVisits(configuration)
+	visit, err := time.Parse(time.RFC3339, visits[cardId])
+	if err != nil {
+		return time.Time{}
+	}
+	return visit
+}
+
+// SetLastVisit stores when the comments of a card have been viewed
+func SetLastVisit(cardId int, visit time.Time, configuration utils.Configuration) error {
+	visits := getVisits(configuration)
+	visits[cardId] = visit.UTC().Format(time.RFC3339)
+	visitsFile, err := utils.CreateFile(fmt.Sprintf("%s/db/comment-visits.json", configuration.ConfigDir))
+	if err != nil {
+		return err
+	}
+	defer visitsFile.Close()
+	marshal, err := json.Marshal(visits)
+	if err != nil {
+		return err
+	}
+	_, err = visitsFile.Write(marshal)
+	return err
+}
+
+func getVisits(configuration utils.Configuration) map[int]string {
+	visits := make(map[int]string)
+	content, err := os.ReadFile(fmt.Sprintf("%s/db/comment-visits.json", configuration.ConfigDir))
+	if err == nil {
+		_ = json.Unmarshal(content, &visits)
+	}
+	return visits
+}
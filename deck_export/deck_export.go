@@ -0,0 +1,228 @@
+package deck_export
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"tui-deck/deck_date"
+	"tui-deck/deck_http"
+	"tui-deck/deck_structs"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+const icsLayout = "20060102T150405Z"
+
+// length of the VEVENT entries, that start at the due date
+const eventDuration = time.Hour
+
+// Run handles the "export" sub command: tui-deck export ics --board N | --all [-o file] [--event] [--open]
+func Run(args []string, configuration utils.Configuration) error {
+	if len(args) == 0 || args[0] != "ics" {
+		return errors.New("usage: tui-deck export ics (--board N | --all) [-o file.ics] [--event] [--open]")
+	}
+	flags := flag.NewFlagSet("export ics", flag.ContinueOnError)
+	boardId := flags.Int("board", 0, "id of the board to export")
+	all := flags.Bool("all", false, "export all boards")
+	output := flags.String("o", "", "output file, - for stdout")
+	event := flags.Bool("event", false, "export cards as VEVENT instead of VTODO")
+	open := flags.Bool("open", false, "skip cards marked as done")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *boardId == 0 && !*all {
+		return errors.New("either --board N or --all is required")
+	}
+
+	boards, err := deck_http.GetBoards(configuration)
+	if err != nil {
+		return err
+	}
+	selected := make([]deck_structs.Board, 0)
+	for _, b := range boards {
+		if *all || b.Id == *boardId {
+			selected = append(selected, b)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("board #%d not found", *boardId)
+	}
+
+	stacks := make(map[int][]deck_structs.Stack)
+	for _, b := range selected {
+		stacks[b.Id], err = deck_http.GetStacks(b.Id, configuration)
+		if err != nil {
+			return fmt.Errorf("error getting stacks for board %s: %s", b.Title, err.Error())
+		}
+		if *open {
+			stacks[b.Id] = openCards(stacks[b.Id])
+		}
+	}
+
+	fileName := *output
+	if len(fileName) == 0 {
+		fileName = "deck.ics"
+		if !*all {
+			fileName = fmt.Sprintf("deck-board-%d.ics", *boardId)
+		}
+	}
+	var writer io.Writer = os.Stdout
+	if fileName != "-" {
+		file, err := os.Create(fileName)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	count, err := WriteIcs(writer, selected, stacks, *event, configuration)
+	if err != nil {
+		return err
+	}
+	if fileName != "-" {
+		fmt.Printf("%d cards exported to %s\n", count, fileName)
+	}
+	return nil
+}
+
+// WriteIcs writes one VTODO (or VEVENT) for every card with a due date and returns the number of exported cards
+func WriteIcs(w io.Writer, boards []deck_structs.Board, stacks map[int][]deck_structs.Stack, event bool, configuration utils.Configuration) (int, error) {
+	host := configuration.Url
+	if u, err := url.Parse(configuration.Url); err == nil && len(u.Host) > 0 {
+		host = u.Host
+	}
+	now := time.Now().UTC().Format(icsLayout)
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		fmt.Sprintf("PRODID:-//tui-deck//tui-deck %s//EN", deck_ui.VERSION),
+		"CALSCALE:GREGORIAN",
+	}
+	if len(boards) == 1 {
+		lines = append(lines, fmt.Sprintf("X-WR-CALNAME:%s", escape(boards[0].Title)))
+	} else {
+		lines = append(lines, "X-WR-CALNAME:Deck")
+	}
+
+	count := 0
+	for _, b := range boards {
+		for _, s := range stacks[b.Id] {
+			for _, c := range s.Cards {
+				if len(c.DueDate) == 0 {
+					continue
+				}
+				due, err := deck_date.FromApi(c.DueDate)
+				if err != nil {
+					continue
+				}
+				component := "VTODO"
+				if event {
+					component = "VEVENT"
+				}
+				lines = append(lines,
+					fmt.Sprintf("BEGIN:%s", component),
+					// stable uid, so re-imports update the same entry
+					fmt.Sprintf("UID:deck-card-%d@%s", c.Id, host),
+					fmt.Sprintf("DTSTAMP:%s", now),
+					fmt.Sprintf("SUMMARY:%s", escape(c.Title)),
+				)
+				if event {
+					lines = append(lines,
+						fmt.Sprintf("DTSTART:%s", due.UTC().Format(icsLayout)),
+						// DTEND must be after DTSTART, events last one hour
+						fmt.Sprintf("DTEND:%s", due.Add(eventDuration).UTC().Format(icsLayout)))
+				} else {
+					lines = append(lines, fmt.Sprintf("DUE:%s", due.UTC().Format(icsLayout)))
+					if done, err := deck_date.FromApi(c.Done); err == nil && len(c.Done) > 0 {
+						lines = append(lines,
+							"STATUS:COMPLETED",
+							fmt.Sprintf("COMPLETED:%s", done.UTC().Format(icsLayout)))
+					} else {
+						lines = append(lines, "STATUS:NEEDS-ACTION")
+					}
+				}
+				if len(c.Description) > 0 {
+					lines = append(lines, fmt.Sprintf("DESCRIPTION:%s", escape(utils.FormatDescription(c.Description))))
+				}
+				lines = append(lines, fmt.Sprintf("URL:%s", utils.GetCardUrl(configuration, b.Id, c.Id)))
+
+				categories := []string{escape(s.Title)}
+				for _, l := range c.Labels {
+					categories = append(categories, escape(l.Title))
+				}
+				lines = append(lines, fmt.Sprintf("CATEGORIES:%s", strings.Join(categories, ",")))
+
+				for _, u := range c.AssignedUsers {
+					cuType, principals := "INDIVIDUAL", "users"
+					if u.Type == deck_structs.ParticipantGroup {
+						cuType, principals = "GROUP", "groups"
+					} else if u.Type == deck_structs.ParticipantCircle {
+						cuType, principals = "GROUP", "circles"
+					}
+					lines = append(lines, fmt.Sprintf("ATTENDEE;CN=%s;CUTYPE=%s:%s/remote.php/dav/principals/%s/%s",
+						quoteParam(u.Participant.DisplayName), cuType, strings.TrimSuffix(configuration.Url, "/"), principals, url.PathEscape(u.Participant.Uid)))
+				}
+				lines = append(lines, fmt.Sprintf("END:%s", component))
+				count++
+			}
+		}
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, l := range lines {
+		if _, err := io.WriteString(w, fold(l)); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// openCards returns the stacks without the cards marked as done
+func openCards(stacks []deck_structs.Stack) []deck_structs.Stack {
+	filtered := make([]deck_structs.Stack, 0, len(stacks))
+	for _, s := range stacks {
+		cards := make([]deck_structs.Card, 0, len(s.Cards))
+		for _, c := range s.Cards {
+			if len(c.Done) == 0 {
+				cards = append(cards, c)
+			}
+		}
+		s.Cards = cards
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
+
+// escape escapes a TEXT value as described in RFC 5545 3.3.11
+func escape(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+func quoteParam(value string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(value, `"`, "'"))
+}
+
+// fold splits content lines longer than 75 octets, without breaking utf-8 sequences, and terminates them with CRLF
+func fold(line string) string {
+	var builder strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > 75 {
+			builder.WriteString("\r\n ")
+			length = 1
+		}
+		builder.WriteRune(r)
+		length += size
+	}
+	builder.WriteString("\r\n")
+	return builder.String()
+}
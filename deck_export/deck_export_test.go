@@ -0,0 +1,73 @@
+package deck_export
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{"a, b; c", `a\, b\; c`},
+		{`C:\path`, `C:\\path`},
+		{"one\ntwo\r\nthree", `one\ntwo\nthree`},
+		{`\,`, `\\\,`},
+	}
+	for _, test := range tests {
+		if got := escape(test.text); got != test.want {
+			t.Errorf("escape(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestQuoteParam(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Alice", `"Alice"`},
+		{`say "hi"`, `"say 'hi'"`},
+		{"a;b:c", `"a;b:c"`},
+	}
+	for _, test := range tests {
+		if got := quoteParam(test.value); got != test.want {
+			t.Errorf("quoteParam(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short", "SUMMARY:short", "SUMMARY:short\r\n"},
+		{"75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{"two folds", strings.Repeat("a", 75+74+1), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		// a 2 octets rune doesn't fit in the last octet of the line
+		{"utf-8", strings.Repeat("a", 74) + "é", strings.Repeat("a", 74) + "\r\n é\r\n"},
+	}
+	for _, test := range tests {
+		got := fold(test.line)
+		if got != test.want {
+			t.Errorf("%s: fold() = %q, want %q", test.name, got, test.want)
+		}
+		for _, l := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+			if len(l) > 75 {
+				t.Errorf("%s: folded line of %d octets", test.name, len(l))
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("%s: folded line %q is not valid utf-8", test.name, l)
+			}
+		}
+		if unfolded := strings.ReplaceAll(strings.TrimSuffix(got, "\r\n"), "\r\n ", ""); unfolded != test.line {
+			t.Errorf("%s: unfolded %q, want %q", test.name, unfolded, test.line)
+		}
+	}
+}
@@ -11,6 +11,10 @@ var HelpLabels = tview.NewTextView()
 var HelpUsers = tview.NewTextView()
 var HelpBoards = tview.NewTextView()
 var HelpComments = tview.NewTextView()
+var HelpCalendar = tview.NewTextView()
+var HelpArchive = tview.NewTextView()
+var HelpAcl = tview.NewTextView()
+var HelpMove = tview.NewTextView()
 
 func InitHelp() {
 	HelpMain = getHelp()
@@ -20,6 +24,10 @@ func InitHelp() {
 	HelpBoards = getHelp5()
 	HelpComments = getHelp6()
 	HelpUsers = getHelp7()
+	HelpCalendar = getHelp8()
+	HelpArchive = getHelp9()
+	HelpAcl = getHelp10()
+	HelpMove = getHelp11()
 }
 
 func getHelp() *tview.TextView {
@@ -32,6 +40,8 @@ func getHelp() *tview.TextView {
 [yellow]Up arrow[white]: Move up.
 [yellow]Right arrow[white]: Move card to next stack.
 [yellow]Left arrow[white]: Move card to previous stack.
+[yellow]shift+Up arrow[white] / [yellow]shift+Down arrow[white]: Move card up / down in the stack.
+[yellow]m[white]: Move card to any stack of this or another board.
 [yellow]ENTER[white]: Select card.
 [yellow]s[white]: Switch board.
 [yellow]r[white]: Reload board.
@@ -40,6 +50,12 @@ func getHelp() *tview.TextView {
 [yellow]ctrl+a[white]: Add stack.
 [yellow]ctrl+d[white]: Delete current stack.
 [yellow]ctrl+e[white]: Edit current stack.
+[yellow]c[white]: Calendar view.
+[yellow]x[white]: Archive selected card.
+[yellow]X[white]: Archived cards of the board.
+[yellow]D[white]: Mark selected card done / not done.
+[yellow]H[white]: Hide / show done cards.
+[yellow]Y[white]: Copy the web url of the selected card.
 [yellow]q[white]: Quit app.
 [yellow]?[white]: Help.
 
@@ -53,12 +69,23 @@ func getHelp2() *tview.TextView {
 		SetDynamicColors(true).
 		SetText(`[green]View Card[white]
 
+[yellow]1[white] - [yellow]4[white]: Show the Description, Comments, Attachments or Activity tab.
 [yellow]e[white]: Edit card Description.
+[yellow]E[white]: Edit card Description in $VISUAL / $EDITOR.
 [yellow]l[white]: Edit card labels.
 [yellow]u[white]: Edit card users.
 [yellow]t[white]: Edit card title.
-[yellow]c[white]: View comments.
-[yellow]ESC[white]: Back to main view.
+[yellow]c[white]: Show the comments tab.
+[yellow]A[white]: Show the activity tab, card activity merged with comments.
+[yellow]x[white]: Archive / unarchive card.
+[yellow]D[white]: Mark card done / not done.
+[yellow]TAB[white] / [yellow]shift+TAB[white]: Move to next / previous task list item.
+[yellow]SPACE[white]: Check / uncheck selected task list item.
+[yellow]o[white]: Open a link or go to a referenced #card, type the number shown next to it.
+[yellow]y[white]: Copy a link to the clipboard, type the number shown next to it.
+[yellow]Y[white]: Copy the card web url.
+[yellow][ / ][white]: Back / forward in the history of referenced cards.
+[yellow]ESC[white]: Back to main view, from the other tabs back to the description.
 
 [blue]Press Enter for more help, press Escape to return.`)
 	HelpView.SetTitle(" HELP - View Card ")
@@ -104,8 +131,15 @@ func getHelp5() *tview.TextView {
 [yellow]a[white]: Add board.
 [yellow]e[white]: Edit board.
 [yellow]d[white]: Delete board.
+[yellow]x[white]: Archive / unarchive board, archived boards are listed at the bottom.
+[yellow]T[white]: Trash, boards deleted recently.
+[yellow]S[white]: Sharing of the board.
 [yellow]ESC[white]: Back to main view.
 
+In the trash:
+[yellow]ENTER[white] / [yellow]r[white]: Restore board.
+[yellow]ESC[white]: Back to the board list.
+
 [blue]Press Enter for more help, press Escape to return.`)
 	HelpBoards.SetTitle(" HELP - Switch Boards ")
 	return HelpBoards
@@ -115,13 +149,22 @@ func getHelp6() *tview.TextView {
 		SetDynamicColors(true).
 		SetText(`[green]View Comments[white]
 
-[yellow]Up arrow[white]: Move up.
-[yellow]Down arrow[white]: Move down.
-[yellow]a[white]: Add comment.
+Newest comments are shown first, older ones are loaded moving past the last comment.
+Comments written after your last visit are marked as [black:green] NEW [-:-:-].
+[yellow]Up arrow[white] / [yellow]k[white]: Previous comment.
+[yellow]Down arrow[white] / [yellow]j[white]: Next comment.
+[yellow]ENTER[white] / [yellow]SPACE[white]: Collapse / expand the replies of the selected comment.
+[yellow]Left arrow[white] / [yellow]Right arrow[white]: Collapse / expand the replies of the selected comment.
+[yellow]a[white]: Add comment (use the Editor button to compose it in $EDITOR).
+[yellow]@[white]: While writing, mention a user: [yellow]TAB[white] completes, [yellow]ctrl+n[white] / [yellow]ctrl+p[white] select the user.
 [yellow]r[white]: Reply comment.
 [yellow]e[white]: Edit comment.
 [yellow]d[white]: Delete comment.
-[yellow]ESC[white]: Back to card view.
+[yellow]o[white]: Open a link or go to a referenced #card, type the number shown next to it.
+[yellow]y[white]: Copy a link to the clipboard, type the number shown next to it.
+[yellow]Y[white]: Copy the card web url.
+[yellow]1[white] - [yellow]4[white]: Switch card tab.
+[yellow]ESC[white]: Back to the card description.
 
 [blue]Press Enter for more help, press Escape to return.`)
 	HelpComments.SetTitle(" HELP - View Comments ")
@@ -137,9 +180,86 @@ func getHelp7() *tview.TextView {
 [yellow]Down arrow[white]: Move down.
 [yellow]TAB[white]: Switch between card users and available users list.
 [yellow]ENTER[white]: If card user has been selected, delete it. If available user has been selected, add it to card
+Groups and circles the board is shared with are listed after the users and can be assigned too.
 [yellow]ESC[white]: Back to card view.
 
 [blue]Press Enter for more help, press Escape to return.`)
 	HelpUsers.SetTitle(" HELP - Edit Card Users ")
 	return HelpUsers
 }
+
+func getHelp8() *tview.TextView {
+	HelpCalendar = tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[green]Calendar[white]
+
+[yellow]Arrows[white]: Move between days.
+[yellow]n[white]: Next month (next week in week view).
+[yellow]p[white]: Previous month (previous week in week view).
+[yellow]t[white]: Go to today.
+[yellow]w[white]: Toggle month and week view.
+[yellow]b[white]: Toggle current board and all boards.
+[yellow]TAB[white]: Switch between calendar and cards of the selected day.
+[yellow]ENTER[white]: Open selected card.
+[yellow]m[white]: Pick selected card to reschedule, then move to a day and press ENTER.
+[yellow]ESC[white]: Cancel reschedule, back to main view.
+
+[blue]Press Enter for more help, press Escape to return.`)
+	HelpCalendar.SetTitle(" HELP - Calendar ")
+	return HelpCalendar
+}
+
+func getHelp9() *tview.TextView {
+	HelpArchive = tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[green]Archived Cards[white]
+
+[yellow]Up arrow[white]: Move up.
+[yellow]Down arrow[white]: Move down.
+[yellow]ENTER[white]: View card.
+[yellow]x[white] / [yellow]u[white]: Unarchive card, putting it back in its stack.
+[yellow]d[white]: Delete card.
+[yellow]ESC[white]: Back to main view.
+
+[blue]Press Enter for more help, press Escape to return.`)
+	HelpArchive.SetTitle(" HELP - Archived Cards ")
+	return HelpArchive
+}
+
+func getHelp10() *tview.TextView {
+	HelpAcl = tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[green]Board Sharing[white]
+
+[yellow]Up arrow[white]: Move up.
+[yellow]Down arrow[white]: Move down.
+[yellow]a[white]: Share with a user, group or circle: type a name and press ENTER to search, TAB to switch to the results.
+[yellow]e[white]: Grant / revoke edit permission.
+[yellow]s[white]: Grant / revoke share permission.
+[yellow]m[white]: Grant / revoke manage permission.
+[yellow]d[white]: Stop sharing.
+[yellow]ESC[white]: Back to the board list.
+
+[blue]Press Enter for more help, press Escape to return.`)
+	HelpAcl.SetTitle(" HELP - Board Sharing ")
+	return HelpAcl
+}
+
+func getHelp11() *tview.TextView {
+	HelpMove = tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[green]Move Card[white]
+
+The stacks of the current board are listed first, then the stacks of the other boards.
+[yellow]Up arrow[white]: Move up.
+[yellow]Down arrow[white]: Move down.
+[yellow]ENTER[white]: Move the card to the top of the selected stack.
+[yellow]1[white] - [yellow]9[white]: Move the card to the stack with that number.
+[yellow]ESC[white]: Back to main view.
+
+Moving to another board, the card labels are replaced with the labels of that board with the same title.
+
+[blue]Press Enter for more help, press Escape to return.`)
+	HelpMove.SetTitle(" HELP - Move Card ")
+	return HelpMove
+}
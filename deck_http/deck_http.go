@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 	"tui-deck/deck_structs"
 	"tui-deck/utils"
@@ -46,18 +47,10 @@ func basicAuth(username, password string) string {
 }
 
 func GetBoards(configuration utils.Configuration) ([]deck_structs.Board, error) {
-	call, err := httpCall(nil, http.MethodGet,
-		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards", configuration.Url),
-		configuration.User, configuration.Password, false)
+	boards, err := getAllBoards(configuration)
 	if err != nil {
 		return nil, err
 	}
-	decoder := json.NewDecoder(call.Body)
-	var boards []deck_structs.Board
-	err = decoder.Decode(&boards)
-	if err != nil {
-		panic(err)
-	}
 
 	filteredBoards := make([]deck_structs.Board, 0)
 	for _, b := range boards {
@@ -69,6 +62,39 @@ func GetBoards(configuration utils.Configuration) ([]deck_structs.Board, error)
 	return filteredBoards, nil
 }
 
+// GetDeletedBoards returns the boards deleted but not yet purged by the server, that can still be restored
+func GetDeletedBoards(configuration utils.Configuration) ([]deck_structs.Board, error) {
+	boards, err := getAllBoards(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedBoards := make([]deck_structs.Board, 0)
+	for _, b := range boards {
+		if b.DeletedAt != 0 {
+			deletedBoards = append(deletedBoards, b)
+		}
+	}
+
+	return deletedBoards, nil
+}
+
+func getAllBoards(configuration utils.Configuration) ([]deck_structs.Board, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards", configuration.Url),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var boards []deck_structs.Board
+	err = decoder.Decode(&boards)
+	if err != nil {
+		panic(err)
+	}
+	return boards, nil
+}
+
 func GetStacks(boardId int, configuration utils.Configuration) ([]deck_structs.Stack, error) {
 	call, err := httpCall(nil, http.MethodGet,
 		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/stacks", configuration.Url, boardId),
@@ -88,6 +114,25 @@ func GetStacks(boardId int, configuration utils.Configuration) ([]deck_structs.S
 	return stacks, nil
 }
 
+// GetArchivedStacks returns the stacks of a board with their archived cards
+func GetArchivedStacks(boardId int, configuration utils.Configuration) ([]deck_structs.Stack, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/stacks/archived", configuration.Url, boardId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(call.Body)
+	var stacks []deck_structs.Stack
+
+	err = decoder.Decode(&stacks)
+	if err != nil {
+		panic(err)
+	}
+	return stacks, nil
+}
+
 func AddCard(boardId int, stackId int, jsonBody string, configuration utils.Configuration) (deck_structs.Card, error) {
 	body := []byte(jsonBody)
 
@@ -109,6 +154,24 @@ func AddCard(boardId int, stackId int, jsonBody string, configuration utils.Conf
 	return card, nil
 }
 
+// GetCard returns the current version of a card on the server
+func GetCard(boardId int, stackId int, cardId int, configuration utils.Configuration) (deck_structs.Card, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/stacks/%d/cards/%d", configuration.Url, boardId, stackId, cardId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return deck_structs.Card{}, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var card deck_structs.Card
+
+	err = decoder.Decode(&card)
+	if err != nil {
+		panic(err)
+	}
+	return card, nil
+}
+
 func UpdateCard(boardId int, stackId int, cardId int, jsonBody string, configuration utils.Configuration) (deck_structs.Card, error) {
 	body := []byte(jsonBody)
 
@@ -128,6 +191,62 @@ func UpdateCard(boardId int, stackId int, cardId int, jsonBody string, configura
 	}
 	return card, nil
 }
+
+// ReorderCard moves a card to a position of a stack, returning the cards of the stack with their new order
+func ReorderCard(boardId int, stackId int, cardId int, jsonBody string, configuration utils.Configuration) ([]deck_structs.Card, error) {
+	body := []byte(jsonBody)
+
+	call, err := httpCall(body, http.MethodPut,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/stacks/%d/cards/%d/reorder", configuration.Url, boardId, stackId, cardId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var raw json.RawMessage
+
+	err = decoder.Decode(&raw)
+	if err != nil {
+		panic(err)
+	}
+	// php encodes the cards keyed by order as an object when the keys are not sorted
+	var cards []deck_structs.Card
+	if json.Unmarshal(raw, &cards) == nil {
+		return cards, nil
+	}
+	var cardsMap map[string]deck_structs.Card
+	err = json.Unmarshal(raw, &cardsMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cardsMap {
+		cards = append(cards, c)
+	}
+	return cards, nil
+}
+
+// ArchiveCard archives a card, or unarchives it when archive is false
+func ArchiveCard(boardId int, stackId int, cardId int, archive bool, configuration utils.Configuration) (deck_structs.Card, error) {
+	action := "archive"
+	if !archive {
+		action = "unarchive"
+	}
+	call, err := httpCall(nil, http.MethodPut,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/stacks/%d/cards/%d/%s", configuration.Url, boardId, stackId, cardId, action),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return deck_structs.Card{}, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var card deck_structs.Card
+
+	err = decoder.Decode(&card)
+	if err != nil {
+		panic(err)
+	}
+	return card, nil
+}
+
 func DeleteCard(boardId int, stackId int, cardId int, configuration utils.Configuration) (deck_structs.Card, error) {
 
 	call, err := httpCall(nil, http.MethodDelete,
@@ -264,6 +383,111 @@ func DeleteBoard(boardId int, configuration utils.Configuration) (deck_structs.B
 	return board, nil
 }
 
+// UndoDeleteBoard restores a deleted board
+func UndoDeleteBoard(boardId int, configuration utils.Configuration) (deck_structs.Board, error) {
+	call, err := httpCall(nil, http.MethodPost,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/undo_delete", configuration.Url, boardId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return deck_structs.Board{}, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var board deck_structs.Board
+
+	err = decoder.Decode(&board)
+	if err != nil {
+		panic(err)
+	}
+	return board, nil
+}
+
+// AddAcl shares a board with a user, a group or a circle
+func AddAcl(boardId int, jsonBody string, configuration utils.Configuration) (deck_structs.Acl, error) {
+	body := []byte(jsonBody)
+
+	call, err := httpCall(body, http.MethodPost,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/acl", configuration.Url, boardId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return deck_structs.Acl{}, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var acl deck_structs.Acl
+
+	err = decoder.Decode(&acl)
+	if err != nil {
+		panic(err)
+	}
+	return acl, nil
+}
+
+// UpdateAcl changes the permissions of a board sharing
+func UpdateAcl(boardId int, aclId int, jsonBody string, configuration utils.Configuration) (deck_structs.Acl, error) {
+	body := []byte(jsonBody)
+
+	call, err := httpCall(body, http.MethodPut,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/acl/%d", configuration.Url, boardId, aclId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return deck_structs.Acl{}, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var acl deck_structs.Acl
+
+	err = decoder.Decode(&acl)
+	if err != nil {
+		panic(err)
+	}
+	return acl, nil
+}
+
+// DeleteAcl removes a board sharing
+func DeleteAcl(boardId int, aclId int, configuration utils.Configuration) (deck_structs.Acl, error) {
+	call, err := httpCall(nil, http.MethodDelete,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/acl/%d", configuration.Url, boardId, aclId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return deck_structs.Acl{}, err
+	}
+	decoder := json.NewDecoder(call.Body)
+	var acl deck_structs.Acl
+
+	err = decoder.Decode(&acl)
+	if err != nil {
+		panic(err)
+	}
+	return acl, nil
+}
+
+// SearchSharees searches the users, groups and circles a board can be shared with, exact matches first
+func SearchSharees(search string, configuration utils.Configuration) ([]deck_structs.Sharee, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/ocs/v2.php/apps/files_sharing/api/v1/sharees?format=json&itemType=deck&lookup=false&perPage=20&search=%s",
+			configuration.Url, url.QueryEscape(search)),
+		configuration.User, configuration.Password, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var ocs deck_structs.OcsResponseSharees
+	decoder := json.NewDecoder(call.Body)
+	err = decoder.Decode(&ocs)
+	if err != nil {
+		panic(err)
+	}
+	// php encodes empty exact matches as []
+	var exact deck_structs.Sharees
+	_ = json.Unmarshal(ocs.Ocs.Data.Exact, &exact)
+
+	sharees := make([]deck_structs.Sharee, 0)
+	for _, s := range []deck_structs.Sharees{exact, ocs.Ocs.Data} {
+		sharees = append(sharees, s.Users...)
+		sharees = append(sharees, s.Groups...)
+		sharees = append(sharees, s.Circles...)
+	}
+	return sharees, nil
+}
+
 func DeleteBoardLabel(boardId int, labelId int, configuration utils.Configuration) (int, error) {
 
 	call, err := httpCall(nil, http.MethodDelete,
@@ -366,10 +590,11 @@ func EditStack(boardId int, stackId int, jsonBody string, configuration utils.Co
 	return stack, nil
 }
 
-func GetComments(cardId int, configuration utils.Configuration) ([]deck_structs.Comment, error) {
+// GetComments returns a page of the card comments, newest first
+func GetComments(cardId int, limit int, offset int, configuration utils.Configuration) ([]deck_structs.Comment, error) {
 
 	call, err := httpCall(nil, http.MethodGet,
-		fmt.Sprintf("%s/ocs/v2.php/apps/deck/api/v1.0/cards/%d/comments", configuration.Url, cardId),
+		fmt.Sprintf("%s/ocs/v2.php/apps/deck/api/v1.0/cards/%d/comments?limit=%d&offset=%d", configuration.Url, cardId, limit, offset),
 		configuration.User, configuration.Password, true)
 
 	if err != nil {
@@ -476,3 +701,80 @@ func AssignUser(boardId int, stackId int, cardId int, jsonBody string, configura
 	}
 	return assingedUser, nil
 }
+
+// GetGroupMembers returns the uids of the users of a group, only visible to admins and group admins
+func GetGroupMembers(groupId string, configuration utils.Configuration) ([]string, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/ocs/v2.php/cloud/groups/%s/users?format=json", configuration.Url, url.PathEscape(groupId)),
+		configuration.User, configuration.Password, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var ocs deck_structs.OcsResponseUsers
+	decoder := json.NewDecoder(call.Body)
+	err = decoder.Decode(&ocs)
+	if err != nil {
+		panic(err)
+	}
+	return ocs.Ocs.Data.Users, nil
+}
+
+// GetCircleMembers returns the members of a circle
+func GetCircleMembers(circleId string, configuration utils.Configuration) ([]deck_structs.CircleMember, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/ocs/v2.php/apps/circles/circles/%s/members?format=json", configuration.Url, url.PathEscape(circleId)),
+		configuration.User, configuration.Password, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var ocs deck_structs.OcsResponseCircleMembers
+	decoder := json.NewDecoder(call.Body)
+	err = decoder.Decode(&ocs)
+	if err != nil {
+		panic(err)
+	}
+	return ocs.Ocs.Data, nil
+}
+
+// GetAttachments returns the files attached to a card
+func GetAttachments(boardId int, stackId int, cardId int, configuration utils.Configuration) ([]deck_structs.Attachment, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/index.php/apps/deck/api/v1.1/boards/%d/stacks/%d/cards/%d/attachments", configuration.Url, boardId, stackId, cardId),
+		configuration.User, configuration.Password, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []deck_structs.Attachment
+	decoder := json.NewDecoder(call.Body)
+	err = decoder.Decode(&attachments)
+	if err != nil {
+		panic(err)
+	}
+	return attachments, nil
+}
+
+// GetActivities returns the newest activities of a card from the activity app
+func GetActivities(cardId int, limit int, configuration utils.Configuration) ([]deck_structs.Activity, error) {
+	call, err := httpCall(nil, http.MethodGet,
+		fmt.Sprintf("%s/ocs/v2.php/apps/activity/api/v2/activity/filter?format=json&object_type=deck_card&object_id=%d&limit=%d&sort=desc",
+			configuration.Url, cardId, limit),
+		configuration.User, configuration.Password, true)
+	if call != nil && call.StatusCode == http.StatusNotModified {
+		// no activities for the card
+		return make([]deck_structs.Activity, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ocs deck_structs.OcsResponseActivities
+	decoder := json.NewDecoder(call.Body)
+	err = decoder.Decode(&ocs)
+	if err != nil {
+		panic(err)
+	}
+	return ocs.Ocs.Data, nil
+}
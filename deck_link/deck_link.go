@@ -0,0 +1,174 @@
+package deck_link
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+var configuration utils.Configuration
+
+// OpenCard shows the card of a #id reference picked in the link picker
+var OpenCard func(cardId int)
+
+// CardUrl returns the web url copied for a #id reference
+var CardUrl func(cardId int) string
+
+var links []string
+var typed string
+var copyLink bool
+var doneFunc func()
+
+func Init(conf utils.Configuration) {
+	configuration = conf
+}
+
+// Pick starts the link picker: the user types the number shown next to a link to open it,
+// or to copy it when copy is set. done is called when the picker is closed
+func Pick(urls []string, copy bool, done func()) bool {
+	if len(urls) == 0 {
+		deck_ui.FooterBar.SetText("No links found")
+		return false
+	}
+	links = urls
+	typed = ""
+	copyLink = copy
+	doneFunc = done
+	showPrompt()
+	return true
+}
+
+// IsPicking reports if the link picker is waiting for a link number
+func IsPicking() bool {
+	return links != nil
+}
+
+// HandleKey handles the keys typed while the link picker is active
+func HandleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case event.Key() == tcell.KeyEscape:
+		stop()
+		deck_ui.FooterBar.SetText("Press [yellow]?[white] for help, [yellow]ESC[white] to go back")
+	case event.Key() == tcell.KeyEnter:
+		choose()
+	case event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2:
+		if len(typed) > 0 {
+			typed = typed[:len(typed)-1]
+		}
+		showPrompt()
+	case event.Rune() >= '0' && event.Rune() <= '9':
+		typed += string(event.Rune())
+		number, _ := strconv.Atoi(typed)
+		// no other link starts with the typed digits
+		if number*10 > len(links) {
+			choose()
+		} else {
+			showPrompt()
+		}
+	}
+	return nil
+}
+
+func showPrompt() {
+	action := "open"
+	if copyLink {
+		action = "copy"
+	}
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Type the number of the link to %s (1-%d) [yellow]%s[white], [yellow]ENTER[white] to confirm, [yellow]ESC[white] to cancel",
+		action, len(links), typed))
+}
+
+func choose() {
+	number, err := strconv.Atoi(typed)
+	if err != nil || number < 1 || number > len(links) {
+		typed = ""
+		deck_ui.FooterBar.SetText(fmt.Sprintf("No link with number %d, type again or press [yellow]ESC[white] to cancel", number))
+		return
+	}
+	link := links[number-1]
+	copy := copyLink
+	stop()
+	if cardId, err := strconv.Atoi(strings.TrimPrefix(link, "#")); err == nil && strings.HasPrefix(link, "#") {
+		if !copy && OpenCard != nil {
+			OpenCard(cardId)
+			return
+		}
+		if CardUrl != nil {
+			link = CardUrl(cardId)
+		}
+	}
+	if copy {
+		err = Copy(link)
+		if err != nil {
+			deck_ui.FooterBar.SetText(fmt.Sprintf("Error copying link: %s", err.Error()))
+			return
+		}
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Copied to clipboard: %s", link))
+		return
+	}
+	err = Open(link)
+	if err != nil {
+		deck_ui.FooterBar.SetText(fmt.Sprintf("Error opening link: %s", err.Error()))
+		return
+	}
+	deck_ui.FooterBar.SetText(fmt.Sprintf("Opening %s", link))
+}
+
+func stop() {
+	links = nil
+	typed = ""
+	if doneFunc != nil {
+		doneFunc()
+	}
+}
+
+// schemes of the links passed to the open command, anything else could be read as an option or a local file
+var openSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Open opens an http, https or mailto link with the configured openCommand, xdg-open (open on macOS) by default
+func Open(link string) error {
+	parsed, err := url.Parse(link)
+	if err != nil || !openSchemes[strings.ToLower(parsed.Scheme)] {
+		return fmt.Errorf("only http, https and mailto links can be opened: %s", link)
+	}
+	command := configuration.OpenCommand
+	if len(command) == 0 {
+		command = "xdg-open"
+		if runtime.GOOS == "darwin" {
+			command = "open"
+		}
+	}
+	args := strings.Fields(command)
+	cmd := exec.Command(args[0], append(args[1:], link)...)
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// Copy puts text in the clipboard of the terminal with an OSC 52 sequence, so it works over ssh too
+func Copy(text string) error {
+	sequence := fmt.Sprintf("\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	if len(os.Getenv("TMUX")) > 0 {
+		// tmux only forwards the sequence to the outer terminal inside a passthrough
+		sequence = fmt.Sprintf("\x1bPtmux;%s\x1b\\", strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b"))
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		_, err = os.Stdout.WriteString(sequence)
+		return err
+	}
+	defer tty.Close()
+	_, err = tty.WriteString(sequence)
+	return err
+}
@@ -0,0 +1,21 @@
+package deck_link
+
+import (
+	"testing"
+)
+
+func TestOpenRefused(t *testing.T) {
+	for _, link := range []string{
+		"--help",
+		"-a /bin/sh",
+		"file:///etc/passwd",
+		"javascript:alert(1)",
+		"/home/user/file.txt",
+		"example.com",
+		"http://[::1",
+	} {
+		if err := Open(link); err == nil {
+			t.Errorf("Open(%q) want an error", link)
+		}
+	}
+}
@@ -2,106 +2,724 @@ package deck_markdown
 
 import (
 	"fmt"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/rivo/tview"
-	"regexp"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"strconv"
 	"strings"
+	"tui-deck/deck_structs"
 	"tui-deck/utils"
+	"unicode"
 )
 
-func GetMarkDownDescription(text string, configuration utils.Configuration) string {
-	var lineContainer = ""
-	var isCodeBlock = false
-	for _, line := range strings.Split(text, "\n") {
-		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "##") || strings.HasPrefix(line, "###") {
-			// # heading
-			line = fmt.Sprintf("[:%s:b]%s[-:-:-]\n", configuration.Color, strings.ReplaceAll(line, "#", "")[1:])
-		} else if strings.Contains(line, "- [ ]") {
-			// task list unselected
-			line = fmt.Sprintf("%s\n", strings.ReplaceAll(line, "- [ ]", "[ ]"))
-		} else if strings.Contains(line, "- [x]") {
-			// task list selected
-			line = fmt.Sprintf("%s\n", strings.ReplaceAll(line, "- [x]", "[✓]"))
-		} else if strings.HasPrefix(line, "- ") {
-			// unordered list
-			line = fmt.Sprintf("%s\n", strings.ReplaceAll(line, "- ", "• "))
-		} else if strings.HasPrefix(line, "> ") {
-			// Blockquotes
-			line = fmt.Sprintf("%s\n", strings.Replace(line, "> ", "│ ", 1))
-		} else if strings.HasPrefix(line, "```") {
-			//codeBlock
-			if !isCodeBlock {
-				line = fmt.Sprintf("[#af0000:#4e4e4e:-]%s\n", strings.ReplaceAll(line, "```", ""))
-				isCodeBlock = true
-			} else {
-				line = fmt.Sprintf("[-:-:-]%s\n", strings.ReplaceAll(line, "```", ""))
+const codeColor = "#af0000"
+const codeBackground = "#4e4e4e"
+const linkTextColor = "#00ffaf"
+const linkColor = "#5f5fff"
+const defaultCodeStyle = "monokai"
+
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithInlineParsers(
+		util.Prioritized(&cardReferenceParser{}, 600),
+		util.Prioritized(&mentionParser{}, 610))))
+
+// CardResolver describes the card referenced by #id, e.g. with its title and stack, ok is false for unknown cards
+var CardResolver func(cardId int) (description string, ok bool)
+
+var kindCardReference = ast.NewNodeKind("CardReference")
+
+// cardReference is a #123 reference to another card
+type cardReference struct {
+	ast.BaseInline
+	CardId int
+}
+
+func (n *cardReference) Kind() ast.NodeKind {
+	return kindCardReference
+}
+
+func (n *cardReference) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"CardId": strconv.Itoa(n.CardId)}, nil)
+}
+
+type cardReferenceParser struct{}
+
+func (p *cardReferenceParser) Trigger() []byte {
+	return []byte{'#'}
+}
+
+func (p *cardReferenceParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	// only at word start, so urls fragments and entities like &#123; are left alone
+	if previous := block.PrecendingCharacter(); !unicode.IsSpace(previous) && !strings.ContainsRune("([{,;:", previous) {
+		return nil
+	}
+	line, _ := block.PeekLine()
+	end := 1
+	for end < len(line) && line[end] >= '0' && line[end] <= '9' {
+		end++
+	}
+	if end == 1 || (end < len(line) && (unicode.IsLetter(rune(line[end])) || line[end] == '_')) {
+		return nil
+	}
+	cardId, err := strconv.Atoi(string(line[1:end]))
+	if err != nil {
+		return nil
+	}
+	block.Advance(end)
+	return &cardReference{CardId: cardId}
+}
+
+var bullets = []string{"•", "◦", "▪"}
+
+type style struct {
+	fg    string
+	bg    string
+	attrs string
+}
+
+var kindMention = ast.NewNodeKind("Mention")
+
+// mention is a @uid or @"uid with spaces" user mention
+type mention struct {
+	ast.BaseInline
+	Uid string
+	Raw string
+}
+
+func (n *mention) Kind() ast.NodeKind {
+	return kindMention
+}
+
+func (n *mention) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Uid": n.Uid}, nil)
+}
+
+type mentionParser struct{}
+
+func (p *mentionParser) Trigger() []byte {
+	return []byte{'@'}
+}
+
+func (p *mentionParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if previous := block.PrecendingCharacter(); !unicode.IsSpace(previous) && !strings.ContainsRune("([{,;:", previous) {
+		return nil
+	}
+	line, _ := block.PeekLine()
+	if len(line) > 2 && line[1] == '"' {
+		end := strings.IndexByte(string(line[2:]), '"')
+		if end <= 0 {
+			return nil
+		}
+		block.Advance(end + 3)
+		return &mention{Uid: string(line[2 : end+2]), Raw: string(line[:end+3])}
+	}
+	end := 1
+	for end < len(line) && IsMentionRune(rune(line[end])) {
+		end++
+	}
+	// a trailing dot ends the sentence
+	for end > 1 && line[end-1] == '.' {
+		end--
+	}
+	if end == 1 {
+		return nil
+	}
+	block.Advance(end)
+	return &mention{Uid: string(line[1:end]), Raw: string(line[:end])}
+}
+
+// IsMentionRune reports if r can be part of an unquoted @uid mention
+func IsMentionRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-@.'", r))
+}
+
+type renderer struct {
+	source        []byte
+	configuration utils.Configuration
+	styles        []style
+	pending       strings.Builder
+	regions       bool
+	tasks         int
+	hints         bool
+	links         int
+	mentions      map[string]string
+}
+
+// renderOptions are the rendering variants of descriptions and comments
+type renderOptions struct {
+	// wrap every task item in a "task-N" region
+	regions bool
+	// prefix every link with its number, numbers start after first
+	hints bool
+	first int
+	// users mentioned in a comment
+	mentions []deck_structs.Mention
+}
+
+// render parses CommonMark / GFM text and renders it into tview color tags
+func render(input string, configuration utils.Configuration, options renderOptions) string {
+	source := []byte(input)
+	document := markdown.Parser().Parse(text.NewReader(source))
+	r := renderer{source: source, configuration: configuration, regions: options.regions, hints: options.hints, links: options.first,
+		mentions: make(map[string]string)}
+	for _, m := range options.mentions {
+		r.mentions[m.MentionId] = m.MentionDisplayName
+	}
+	return strings.Join(r.renderBlocks(document, true), "\n") + "\n"
+}
+
+// GetMarkDownDescription renders CommonMark / GFM text into tview color tags
+func GetMarkDownDescription(description string, configuration utils.Configuration) string {
+	return render(description, configuration, renderOptions{})
+}
+
+// GetMarkDownDescriptionRegions renders like GetMarkDownDescription, wrapping every
+// task item in a "task-N" region, for text views with regions enabled
+func GetMarkDownDescriptionRegions(description string, configuration utils.Configuration) string {
+	return render(description, configuration, renderOptions{regions: true})
+}
+
+// GetMarkDownDescriptionHints renders like GetMarkDownDescription, prefixing every link
+// with its number for the link picker, numbers start after first
+func GetMarkDownDescriptionHints(description string, configuration utils.Configuration, first int) string {
+	return render(description, configuration, renderOptions{hints: true, first: first})
+}
+
+// GetMarkDownComment renders a comment message highlighting the mentioned users,
+// numbering links after first when hints is set
+func GetMarkDownComment(message string, mentions []deck_structs.Mention, configuration utils.Configuration, hints bool, first int) string {
+	return render(message, configuration, renderOptions{hints: hints, first: first, mentions: mentions})
+}
+
+// GetLinks returns the destinations of links, autolinks and images and the #id card references in rendering order
+func GetLinks(description string) []string {
+	source := []byte(description)
+	links := make([]string, 0)
+	document := markdown.Parser().Parse(text.NewReader(source))
+	_ = ast.Walk(document, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Link:
+			links = append(links, string(node.Destination))
+		case *ast.Image:
+			links = append(links, string(node.Destination))
+		case *ast.AutoLink:
+			links = append(links, autoLinkUrl(node, source))
+		case *cardReference:
+			links = append(links, fmt.Sprintf("#%d", node.CardId))
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// CountTasks returns the number of checked and total task list items
+func CountTasks(description string) (int, int) {
+	done, total := 0, 0
+	for _, task := range getTasks([]byte(description)) {
+		total++
+		if task.IsChecked {
+			done++
+		}
+	}
+	return done, total
+}
+
+// ToggleTask checks or unchecks the task item at index and returns the updated markdown
+func ToggleTask(description string, index int) (string, bool) {
+	source := []byte(description)
+	tasks := getTasks(source)
+	if index < 0 || index >= len(tasks) {
+		return description, false
+	}
+	block := tasks[index].Parent()
+	if block == nil || block.Lines().Len() == 0 {
+		return description, false
+	}
+	start := block.Lines().At(0).Start
+	open := strings.Index(description[start:], "[")
+	if open < 0 || start+open+2 >= len(description) {
+		return description, false
+	}
+	position := start + open + 1
+	mark := "x"
+	if tasks[index].IsChecked {
+		mark = " "
+	}
+	return description[:position] + mark + description[position+1:], true
+}
+
+func getTasks(source []byte) []*east.TaskCheckBox {
+	tasks := make([]*east.TaskCheckBox, 0)
+	document := markdown.Parser().Parse(text.NewReader(source))
+	_ = ast.Walk(document, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if task, ok := n.(*east.TaskCheckBox); ok && entering {
+			tasks = append(tasks, task)
+		}
+		return ast.WalkContinue, nil
+	})
+	return tasks
+}
+
+func (r *renderer) renderBlocks(parent ast.Node, loose bool) []string {
+	lines := make([]string, 0)
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		if len(lines) > 0 && loose {
+			lines = append(lines, "")
+		}
+		lines = append(lines, r.renderBlock(n)...)
+	}
+	return lines
+}
+
+func (r *renderer) renderBlock(n ast.Node) []string {
+	switch node := n.(type) {
+	case *ast.Heading:
+		return r.renderHeading(node)
+	case *ast.Paragraph, *ast.TextBlock:
+		if _, ok := n.FirstChild().(*east.TaskCheckBox); ok && r.regions {
+			region := fmt.Sprintf(`["task-%d"]`, r.tasks)
+			r.tasks++
+			return strings.Split(region+r.renderInlines(node)+`[""]`, "\n")
+		}
+		return strings.Split(r.renderInlines(node), "\n")
+	case *ast.List:
+		return r.renderList(node)
+	case *ast.Blockquote:
+		return prefixLines(r.renderBlocks(node, true), "│ ", "│ ")
+	case *ast.FencedCodeBlock:
+		return r.renderCode(node)
+	case *ast.CodeBlock:
+		return r.renderCode(node)
+	case *ast.ThematicBreak:
+		return []string{fmt.Sprintf("[%s]%s[-:-:-]", r.configuration.Color, strings.Repeat("─", 40))}
+	case *ast.HTMLBlock:
+		lines := make([]string, 0)
+		for i := 0; i < node.Lines().Len(); i++ {
+			segment := node.Lines().At(i)
+			lines = append(lines, tview.Escape(strings.TrimRight(string(segment.Value(r.source)), "\n")))
+		}
+		return lines
+	case *east.Table:
+		return r.renderTable(node)
+	default:
+		return r.renderBlocks(n, true)
+	}
+}
+
+func (r *renderer) renderHeading(node *ast.Heading) []string {
+	var s style
+	switch node.Level {
+	case 1:
+		s = style{bg: r.configuration.Color, attrs: "b"}
+	case 2:
+		s = style{fg: r.configuration.Color, attrs: "bu"}
+	case 3:
+		s = style{fg: r.configuration.Color, attrs: "b"}
+	default:
+		s = style{fg: r.configuration.Color, attrs: "i"}
+	}
+	r.styles = []style{s}
+	content := r.renderInlines(node)
+	if node.Level == 1 {
+		content = fmt.Sprintf(" %s ", content)
+	}
+	content = r.tag() + content + "[-:-:-]"
+	r.styles = nil
+	return strings.Split(content, "\n")
+}
+
+func (r *renderer) renderList(node *ast.List) []string {
+	lines := make([]string, 0)
+	depth := 0
+	for p := node.Parent(); p != nil; p = p.Parent() {
+		if _, ok := p.(*ast.List); ok {
+			depth++
+		}
+	}
+	number := node.Start
+	for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := bullets[depth%len(bullets)] + " "
+		if node.IsOrdered() {
+			marker = fmt.Sprintf("%d%c ", number, node.Marker)
+			number++
+		}
+		if len(lines) > 0 && !node.IsTight {
+			lines = append(lines, "")
+		}
+		itemLines := r.renderBlocks(item, !node.IsTight)
+		if len(itemLines) == 0 {
+			itemLines = []string{""}
+		}
+		lines = append(lines, prefixLines(itemLines, marker, strings.Repeat(" ", len([]rune(marker))))...)
+	}
+	return lines
+}
+
+func (r *renderer) renderCode(node ast.Node) []string {
+	var code strings.Builder
+	for i := 0; i < node.Lines().Len(); i++ {
+		segment := node.Lines().At(i)
+		code.Write(segment.Value(r.source))
+	}
+
+	if fenced, ok := node.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+		if lexer := lexers.Get(string(fenced.Language(r.source))); lexer != nil {
+			if lines, err := r.highlight(lexer, code.String()); err == nil {
+				return lines
 			}
-		} else if len(checkLink(line)) > 0 {
-			result := checkLink(line)
-			for _, r := range result {
-				rTmp := strings.ReplaceAll(r[1], "[", "")
-				r[1] = strings.ReplaceAll(rTmp, "]", "")
-				line = fmt.Sprintf("%s", strings.ReplaceAll(line, r[0], fmt.Sprintf("[#00ffaf]%s [#5f5fff:-:u]%s[-:-:-]", strings.ReplaceAll(r[1], "[", ""), r[2])))
+		}
+	}
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimRight(code.String(), "\n"), "\n") {
+		lines = append(lines, fmt.Sprintf("[%s:%s:-]%s[-:-:-]", codeColor, codeBackground, tview.Escape(line)))
+	}
+	return lines
+}
+
+// highlight colors code with the chroma style set in the configuration, or with the theme color when none is set
+func (r *renderer) highlight(lexer chroma.Lexer, code string) ([]string, error) {
+	codeStyle := styles.Get(r.configuration.CodeStyle)
+	if len(r.configuration.CodeStyle) == 0 {
+		codeStyle = themeStyle(r.configuration.Color)
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return nil, err
+	}
+
+	background := "-"
+	if entry := codeStyle.Get(chroma.Background); entry.Background.IsSet() {
+		background = entry.Background.String()
+	}
+
+	lines := make([]string, 0)
+	var line strings.Builder
+	for _, token := range iterator.Tokens() {
+		entry := codeStyle.Get(token.Type)
+		foreground := "-"
+		if entry.Colour.IsSet() {
+			foreground = entry.Colour.String()
+		}
+		attrs := ""
+		if entry.Bold == chroma.Yes {
+			attrs += "b"
+		}
+		if entry.Italic == chroma.Yes {
+			attrs += "i"
+		}
+		if entry.Underline == chroma.Yes {
+			attrs += "u"
+		}
+		tag := fmt.Sprintf("[%s:%s:%s]", foreground, background, orDefault(attrs))
+		for i, part := range strings.Split(token.Value, "\n") {
+			if i > 0 {
+				lines = append(lines, line.String()+"[-:-:-]")
+				line.Reset()
+			}
+			if len(part) > 0 {
+				line.WriteString(tag + tview.Escape(part))
 			}
-		} else {
-			line = fmt.Sprintf("%s\n", tview.Escape(line))
 		}
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String()+"[-:-:-]")
+	}
+	return lines, nil
+}
 
-		// bold + italic
-		result := getStringInBetween(line, "\\*\\*\\*", "\\*\\*\\*")
-		if len(result) > 0 {
-			for _, r := range result {
-				line = fmt.Sprintf("%s", strings.ReplaceAll(line, r[1], fmt.Sprintf("[::bi]%s[::-]", r[2])))
+// themeStyle is the default code style with keywords, functions and types in the theme color
+func themeStyle(color string) *chroma.Style {
+	base := styles.Get(defaultCodeStyle)
+	hex := utils.GetColor(color).Hex()
+	if hex < 0 {
+		return base
+	}
+	theme := fmt.Sprintf("#%06x", hex)
+	style, err := base.Builder().
+		Add(chroma.Keyword, theme+" bold").
+		Add(chroma.KeywordType, theme).
+		Add(chroma.NameFunction, theme).
+		Add(chroma.NameClass, theme).
+		Build()
+	if err != nil {
+		return base
+	}
+	return style
+}
+
+func (r *renderer) renderTable(node *east.Table) []string {
+	rows := make([][]string, 0)
+	for row := node.FirstChild(); row != nil; row = row.NextSibling() {
+		cells := make([]string, 0)
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			if _, ok := row.(*east.TableHeader); ok {
+				r.styles = []style{{fg: r.configuration.Color, attrs: "b"}}
+				cells = append(cells, r.tag()+r.renderInlines(cell)+"[-:-:-]")
+				r.styles = nil
+			} else {
+				cells = append(cells, r.renderInlines(cell))
 			}
-			line = fmt.Sprintf("%s", strings.ReplaceAll(line, "***", ""))
 		}
-		// bold
-		result = getStringInBetween(line, "\\*\\*", "\\*\\*")
-		if len(result) > 0 {
-			for _, r := range result {
-				line = fmt.Sprintf("%s", strings.ReplaceAll(line, r[1], fmt.Sprintf("[::b]%s[::-]", r[2])))
+		rows = append(rows, cells)
+	}
+
+	widths := make([]int, len(node.Alignments))
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && tview.TaggedStringWidth(cell) > widths[i] {
+				widths[i] = tview.TaggedStringWidth(cell)
 			}
-			line = fmt.Sprintf("%s", strings.ReplaceAll(line, "**", ""))
 		}
-		// italic
-		result = getStringInBetween(line, "\\*", "\\*")
-		if len(result) > 0 {
+	}
 
-			for _, r := range result {
-				line = fmt.Sprintf("%s", strings.ReplaceAll(line, r[1], fmt.Sprintf("[::i]%s[::-]", r[2])))
+	lines := make([]string, 0)
+	for i, row := range rows {
+		cells := make([]string, len(widths))
+		for j := range widths {
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
 			}
-			line = fmt.Sprintf("%s", strings.ReplaceAll(line, "*", ""))
+			cells[j] = align(cell, widths[j], node.Alignments[j])
 		}
-		// code inline
-		result = getStringInBetween(line, "\\`", "\\`")
-		if len(result) > 0 {
-			for _, r := range result {
-				line = fmt.Sprintf("%s", strings.ReplaceAll(line, r[1], fmt.Sprintf("[#af0000:#4e4e4e:-] %s [::-]", r[2])))
+		lines = append(lines, strings.Join(cells, " │ "))
+		if i == 0 {
+			separators := make([]string, len(widths))
+			for j, w := range widths {
+				separators[j] = strings.Repeat("─", w)
 			}
-			line = fmt.Sprintf("%s", strings.ReplaceAll(line, "`", ""))
+			lines = append(lines, strings.Join(separators, "─┼─"))
 		}
+	}
+	return lines
+}
 
-		lineContainer = lineContainer + fmt.Sprintf("%s", line)
+func align(cell string, width int, alignment east.Alignment) string {
+	padding := width - tview.TaggedStringWidth(cell)
+	if padding <= 0 {
+		return cell
+	}
+	switch alignment {
+	case east.AlignRight:
+		return strings.Repeat(" ", padding) + cell
+	case east.AlignCenter:
+		return strings.Repeat(" ", padding/2) + cell + strings.Repeat(" ", padding-padding/2)
+	default:
+		return cell + strings.Repeat(" ", padding)
 	}
+}
 
-	return lineContainer
+func (r *renderer) renderInlines(parent ast.Node) string {
+	var builder strings.Builder
+	r.inlines(parent, &builder)
+	r.flush(&builder)
+	return builder.String()
 }
 
-func getStringInBetween(str string, start string, end string) (result [][]string) {
-	re := regexp.MustCompile("(" + start + `(.*?)` + end + ")")
-	match := re.FindAllStringSubmatch(str, -1)
-	if len(match) > 0 {
-		return match
-	} else {
+func (r *renderer) inlines(parent ast.Node, builder *strings.Builder) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.Text:
+			value := node.Segment.Value(r.source)
+			if !node.IsRaw() {
+				value = util.ResolveEntityNames(util.ResolveNumericReferences(util.UnescapePunctuations(value)))
+			}
+			r.pending.Write(value)
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				r.flush(builder)
+				builder.WriteString("[-:-:-]\n" + r.tag())
+			}
+		case *ast.String:
+			r.pending.Write(node.Value)
+		case *ast.Emphasis:
+			attrs := "i"
+			if node.Level > 1 {
+				attrs = "b"
+			}
+			r.push(builder, style{attrs: attrs})
+			r.inlines(node, builder)
+			r.pop(builder)
+		case *east.Strikethrough:
+			r.push(builder, style{attrs: "s"})
+			r.inlines(node, builder)
+			r.pop(builder)
+		case *ast.CodeSpan:
+			r.push(builder, style{fg: codeColor, bg: codeBackground})
+			r.pending.WriteString(" ")
+			for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					r.pending.Write(t.Segment.Value(r.source))
+				}
+			}
+			r.pending.WriteString(" ")
+			r.pop(builder)
+		case *ast.Link:
+			r.hint(builder)
+			r.push(builder, style{fg: linkTextColor})
+			r.inlines(node, builder)
+			r.pop(builder)
+			r.pending.WriteString(" ")
+			r.push(builder, style{fg: linkColor, attrs: "u"})
+			r.pending.Write(node.Destination)
+			r.pop(builder)
+		case *ast.AutoLink:
+			r.hint(builder)
+			r.push(builder, style{fg: linkColor, attrs: "u"})
+			r.pending.Write(node.URL(r.source))
+			r.pop(builder)
+		case *ast.Image:
+			r.hint(builder)
+			r.push(builder, style{fg: linkTextColor})
+			r.pending.WriteString("image: ")
+			r.inlines(node, builder)
+			r.pop(builder)
+			r.pending.WriteString(" ")
+			r.push(builder, style{fg: linkColor, attrs: "u"})
+			r.pending.Write(node.Destination)
+			r.pop(builder)
+		case *ast.RawHTML:
+			for i := 0; i < node.Segments.Len(); i++ {
+				segment := node.Segments.At(i)
+				r.pending.Write(segment.Value(r.source))
+			}
+		case *cardReference:
+			r.hint(builder)
+			color := r.configuration.Color
+			if r.current().bg == color {
+				// level 1 headings use the theme color as background
+				color = "white"
+			}
+			r.push(builder, style{fg: color, attrs: "b"})
+			r.pending.WriteString(fmt.Sprintf("#%d", node.CardId))
+			r.pop(builder)
+			if CardResolver != nil {
+				if description, ok := CardResolver(node.CardId); ok {
+					r.push(builder, style{fg: "gray"})
+					r.pending.WriteString(fmt.Sprintf(" (%s)", description))
+					r.pop(builder)
+				}
+			}
+		case *mention:
+			name, ok := r.mentions[node.Uid]
+			if !ok {
+				r.pending.WriteString(node.Raw)
+				break
+			}
+			if node.Uid == r.configuration.User {
+				r.push(builder, style{fg: "black", bg: "yellow", attrs: "b"})
+			} else {
+				r.push(builder, style{fg: "yellow", attrs: "b"})
+			}
+			r.pending.WriteString("@" + name)
+			r.pop(builder)
+		case *east.TaskCheckBox:
+			if node.IsChecked {
+				r.pending.WriteString("[✓] ")
+			} else {
+				r.pending.WriteString("[ ] ")
+			}
+		default:
+			r.inlines(n, builder)
+		}
+	}
+}
+
+// hint writes the number of the next link when rendering for the link picker
+func (r *renderer) hint(builder *strings.Builder) {
+	if !r.hints {
 		return
 	}
+	r.flush(builder)
+	r.links++
+	builder.WriteString(fmt.Sprintf("[black:yellow:b]%d[-:-:-]%s ", r.links, r.tag()))
+}
+
+// autoLinkUrl returns the url of an autolink, adding the scheme to bare emails and www. links
+func autoLinkUrl(node *ast.AutoLink, source []byte) string {
+	url := string(node.URL(source))
+	if node.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(url, "mailto:") {
+		return "mailto:" + url
+	}
+	if node.AutoLinkType == ast.AutoLinkURL && !strings.Contains(url, "://") {
+		return "https://" + url
+	}
+	return url
 }
 
-func checkLink(str string) (result [][]string) {
-	re := regexp.MustCompile(`(\[[^][]+])\((https?://[^()]+)\)`)
-	match := re.FindAllStringSubmatch(str, -1)
-	if len(match) > 0 {
-		return match
-	} else {
+// flush writes the buffered text escaped, text is buffered so that tag-like
+// sequences split over several nodes are escaped as a whole
+func (r *renderer) flush(builder *strings.Builder) {
+	if r.pending.Len() == 0 {
 		return
 	}
+	builder.WriteString(tview.Escape(r.pending.String()))
+	r.pending.Reset()
+}
+
+func (r *renderer) push(builder *strings.Builder, s style) {
+	r.flush(builder)
+	current := r.current()
+	if len(s.fg) > 0 {
+		current.fg = s.fg
+	}
+	if len(s.bg) > 0 {
+		current.bg = s.bg
+	}
+	if !strings.Contains(current.attrs, s.attrs) {
+		current.attrs += s.attrs
+	}
+	r.styles = append(r.styles, current)
+	builder.WriteString(r.tag())
+}
+
+func (r *renderer) pop(builder *strings.Builder) {
+	r.flush(builder)
+	if len(r.styles) > 0 {
+		r.styles = r.styles[:len(r.styles)-1]
+	}
+	builder.WriteString(r.tag())
+}
+
+func (r *renderer) current() style {
+	if len(r.styles) == 0 {
+		return style{}
+	}
+	return r.styles[len(r.styles)-1]
+}
+
+// tag returns the tview color tag for the current style
+func (r *renderer) tag() string {
+	current := r.current()
+	return fmt.Sprintf("[%s:%s:%s]", orDefault(current.fg), orDefault(current.bg), orDefault(current.attrs))
+}
+
+func orDefault(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
+
+func prefixLines(lines []string, first string, others string) []string {
+	result := make([]string, len(lines))
+	for i, l := range lines {
+		if i == 0 {
+			result[i] = first + l
+		} else {
+			result[i] = others + l
+		}
+	}
+	return result
 }
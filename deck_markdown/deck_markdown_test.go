@@ -0,0 +1,139 @@
+package deck_markdown
+
+import (
+	"testing"
+	"tui-deck/deck_structs"
+	"tui-deck/utils"
+)
+
+func TestCountTasks(t *testing.T) {
+	tests := []struct {
+		description string
+		done        int
+		total       int
+	}{
+		{"", 0, 0},
+		{"no tasks\n\n- a list item", 0, 0},
+		{"- [ ] one\n- [x] two\n- [X] three", 2, 3},
+		{"* [ ] one\n  - [x] nested", 1, 2},
+		{"```\n- [ ] in a code block\n```\n- [ ] real", 0, 1},
+		{"1. [x] ordered", 1, 1},
+	}
+	for _, test := range tests {
+		done, total := CountTasks(test.description)
+		if done != test.done || total != test.total {
+			t.Errorf("CountTasks(%q) = %d, %d, want %d, %d", test.description, done, total, test.done, test.total)
+		}
+	}
+}
+
+func TestToggleTask(t *testing.T) {
+	tests := []struct {
+		description string
+		index       int
+		want        string
+		ok          bool
+	}{
+		{"- [ ] one\n- [ ] two", 0, "- [x] one\n- [ ] two", true},
+		{"- [ ] one\n- [x] two", 1, "- [ ] one\n- [ ] two", true},
+		{"- [X] one", 0, "- [ ] one", true},
+		{"text [ ] before\n\n- [ ] task", 0, "text [ ] before\n\n- [x] task", true},
+		{"* [ ] one\n  - [ ] nested", 1, "* [ ] one\n  - [x] nested", true},
+		{"```\n- [ ] code\n```\n- [ ] real", 0, "```\n- [ ] code\n```\n- [x] real", true},
+		{"- [ ] one", 1, "- [ ] one", false},
+		{"- [ ] one", -1, "- [ ] one", false},
+		{"no tasks", 0, "no tasks", false},
+	}
+	for _, test := range tests {
+		got, ok := ToggleTask(test.description, test.index)
+		if got != test.want || ok != test.ok {
+			t.Errorf("ToggleTask(%q, %d) = %q, %t, want %q, %t", test.description, test.index, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGetMarkDownDescription(t *testing.T) {
+	configuration := utils.Configuration{Color: "#BF40BF", CodeStyle: "monokai"}
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{"heading 1", "# Title", "[-:#BF40BF:b] Title [-:-:-]\n"},
+		{"heading 2", "## Title", "[#BF40BF:-:bu]Title[-:-:-]\n"},
+		{"heading 3", "### Title", "[#BF40BF:-:b]Title[-:-:-]\n"},
+		{"heading 4", "#### Title", "[#BF40BF:-:i]Title[-:-:-]\n"},
+		{"emphasis", "**bold** *italic*", "[-:-:b]bold[-:-:-] [-:-:i]italic[-:-:-]\n"},
+		{"strikethrough", "~~gone~~", "[-:-:s]gone[-:-:-]\n"},
+		{"nested list", "- a\n  - b\n- c", "• a\n  ◦ b\n• c\n"},
+		{"ordered list", "1. one\n2. two\n   1. nested", "1. one\n2. two\n   1. nested\n"},
+		{"tasks", "- [ ] todo\n- [x] done", "• [ [] todo\n• [✓] done\n"},
+		{
+			"table alignment", "| a | b | c |\n|:--|:-:|--:|\n| 1 | 22 | 333 |",
+			"[#BF40BF:-:b]a[-:-:-] │ [#BF40BF:-:b]b[-:-:-]  │   [#BF40BF:-:b]c[-:-:-]\n──┼────┼────\n1 │ 22 │ 333\n",
+		},
+		{"inline code", "use `x[red]y` here", "use [#af0000:#4e4e4e:-] x[red[]y [-:-:-] here\n"},
+		{"fenced code", "```\nplain [red]\n```", "[#af0000:#4e4e4e:-]plain [red[][-:-:-]\n"},
+		{
+			"highlighted code", "```go\nfunc f() {}\n```",
+			"[#66d9ef:#272822:-]func[#f8f8f2:#272822:-] [#a6e22e:#272822:-]f[#f8f8f2:#272822:-]()[#f8f8f2:#272822:-] [#f8f8f2:#272822:-]{}[-:-:-]\n",
+		},
+		{"escaped tags", "text [red]not a tag[-]", "text [red[]not a tag[-[]\n"},
+	}
+	for _, test := range tests {
+		if got := GetMarkDownDescription(test.description, configuration); got != test.want {
+			t.Errorf("%s: GetMarkDownDescription(%q) = %q, want %q", test.name, test.description, got, test.want)
+		}
+	}
+}
+
+func TestGetMarkDownDescriptionRegions(t *testing.T) {
+	got := GetMarkDownDescriptionRegions("- [ ] todo\n- [x] done", utils.Configuration{})
+	want := "• [\"task-0\"][ [] todo[\"\"]\n• [\"task-1\"][✓] done[\"\"]\n"
+	if got != want {
+		t.Errorf("GetMarkDownDescriptionRegions() = %q, want %q", got, want)
+	}
+}
+
+func TestGetMarkDownDescriptionHints(t *testing.T) {
+	got := GetMarkDownDescriptionHints("see [a](http://x) and <http://y>", utils.Configuration{}, 2)
+	want := "see [black:yellow:b]3[-:-:-][-:-:-] [#00ffaf:-:-]a[-:-:-] [#5f5fff:-:u]http://x[-:-:-] and " +
+		"[black:yellow:b]4[-:-:-][-:-:-] [#5f5fff:-:u]http://y[-:-:-]\n"
+	if got != want {
+		t.Errorf("GetMarkDownDescriptionHints() = %q, want %q", got, want)
+	}
+}
+
+func TestGetMarkDownCommentMentions(t *testing.T) {
+	configuration := utils.Configuration{User: "me"}
+	mentions := []deck_structs.Mention{
+		{MentionId: "alice", MentionDisplayName: "Alice"},
+		{MentionId: "john.doe", MentionDisplayName: "John"},
+		{MentionId: "mary-jane", MentionDisplayName: "Mary"},
+		{MentionId: "bob@example.com", MentionDisplayName: "Bob"},
+		{MentionId: "guest user", MentionDisplayName: "Guest"},
+		{MentionId: "me", MentionDisplayName: "Me"},
+	}
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"mention", "hi @alice", "hi [yellow:-:b]@Alice[-:-:-]\n"},
+		{"after a bracket", "(@alice)", "([yellow:-:b]@Alice[-:-:-])\n"},
+		{"mid-word", "mail@alice", "mail@alice\n"},
+		{"email address", "write to bob@example.com", "write to [#5f5fff:-:u]bob@example.com[-:-:-]\n"},
+		{"email uid", "@bob@example.com please", "[yellow:-:b]@Bob[-:-:-] please\n"},
+		{"uid with dots", "thanks @john.doe.", "thanks [yellow:-:b]@John[-:-:-].\n"},
+		{"uid with dashes", "@mary-jane, look", "[yellow:-:b]@Mary[-:-:-], look\n"},
+		{"quoted uid", "@\"guest user\" hi", "[yellow:-:b]@Guest[-:-:-] hi\n"},
+		{"current user", "@me", "[black:yellow:b]@Me[-:-:-]\n"},
+		{"unknown user", "@unknown", "@unknown\n"},
+		{"lone at", "a @ b", "a @ b\n"},
+	}
+	for _, test := range tests {
+		if got := GetMarkDownComment(test.message, mentions, configuration, false, 0); got != test.want {
+			t.Errorf("%s: GetMarkDownComment(%q) = %q, want %q", test.name, test.message, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package deck_merge
+
+import (
+	"strings"
+)
+
+const (
+	oursMarker     = "<<<<<<< ours"
+	originalMarker = "||||||| original"
+	separator      = "======="
+	theirsMarker   = ">>>>>>> theirs"
+)
+
+// Merge merges the changes of ours and theirs to original line by line, like diff3.
+// Lines changed on both sides in different ways are left between conflict markers,
+// conflict reports if there are any
+func Merge(original string, ours string, theirs string) (merged string, conflict bool) {
+	base := strings.Split(original, "\n")
+	a := strings.Split(ours, "\n")
+	b := strings.Split(theirs, "\n")
+	matchA := match(base, a)
+	matchB := match(base, b)
+
+	lines := make([]string, 0)
+	i, j, k := 0, 0, 0
+	for n := range base {
+		// lines unchanged on both sides split the text in chunks
+		if matchA[n] < 0 || matchB[n] < 0 {
+			continue
+		}
+		chunk, c := mergeChunk(base[i:n], a[j:matchA[n]], b[k:matchB[n]])
+		lines = append(append(lines, chunk...), base[n])
+		conflict = conflict || c
+		i, j, k = n+1, matchA[n]+1, matchB[n]+1
+	}
+	chunk, c := mergeChunk(base[i:], a[j:], b[k:])
+	lines = append(lines, chunk...)
+	return strings.Join(lines, "\n"), conflict || c
+}
+
+// HasMarkers reports if text still contains conflict markers
+func HasMarkers(text string) bool {
+	for _, l := range strings.Split(text, "\n") {
+		if l == oursMarker || l == theirsMarker {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeChunk(base []string, a []string, b []string) ([]string, bool) {
+	switch {
+	case equal(a, base):
+		return b, false
+	case equal(b, base), equal(a, b):
+		return a, false
+	}
+	lines := append([]string{oursMarker}, a...)
+	lines = append(append(lines, originalMarker), base...)
+	lines = append(append(lines, separator), b...)
+	return append(lines, theirsMarker), true
+}
+
+// match returns for each line of base the index of the same line in other, -1 if removed,
+// following the longest common subsequence
+func match(base []string, other []string) []int {
+	lcs := make([][]int, len(base)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(other)+1)
+	}
+	for i := len(base) - 1; i >= 0; i-- {
+		for j := len(other) - 1; j >= 0; j-- {
+			if base[i] == other[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	matches := make([]int, len(base))
+	i, j := 0, 0
+	for i < len(base) {
+		switch {
+		case j < len(other) && base[i] == other[j]:
+			matches[i] = j
+			i++
+			j++
+		case j < len(other) && lcs[i][j+1] > lcs[i+1][j]:
+			j++
+		default:
+			matches[i] = -1
+			i++
+		}
+	}
+	return matches
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package deck_merge
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		ours     string
+		theirs   string
+		merged   string
+		conflict bool
+	}{
+		{"unchanged", "a\nb\nc", "a\nb\nc", "a\nb\nc", "a\nb\nc", false},
+		{"only ours", "a\nb\nc", "a\nB\nc", "a\nb\nc", "a\nB\nc", false},
+		{"only theirs", "a\nb\nc", "a\nb\nc", "a\nb\nC", "a\nb\nC", false},
+		{"different lines", "a\nb\nc\nd", "A\nb\nc\nd", "a\nb\nc\nD", "A\nb\nc\nD", false},
+		{"same change", "a\nb\nc", "a\nX\nc", "a\nX\nc", "a\nX\nc", false},
+		{"added and removed", "a\nb\nc\nd", "a\nb\nnew\nc\nd", "a\nb\nc", "a\nb\nnew\nc", false},
+		{"added at both ends", "b", "a\nb", "b\nc", "a\nb\nc", false},
+		{"empty original", "", "ours", "", "ours", false},
+		{
+			"same line", "a\nb\nc", "a\nours\nc", "a\ntheirs\nc",
+			"a\n<<<<<<< ours\nours\n||||||| original\nb\n=======\ntheirs\n>>>>>>> theirs\nc", true,
+		},
+		{
+			"changed and removed", "a\nb\nc", "a\nB\nc", "a\nc",
+			"a\n<<<<<<< ours\nB\n||||||| original\nb\n=======\n>>>>>>> theirs\nc", true,
+		},
+		{
+			"both added", "", "ours", "theirs",
+			"<<<<<<< ours\nours\n||||||| original\n\n=======\ntheirs\n>>>>>>> theirs", true,
+		},
+	}
+	for _, test := range tests {
+		merged, conflict := Merge(test.original, test.ours, test.theirs)
+		if merged != test.merged || conflict != test.conflict {
+			t.Errorf("%s: Merge() = %q, %t, want %q, %t", test.name, merged, conflict, test.merged, test.conflict)
+		}
+		if HasMarkers(merged) != test.conflict {
+			t.Errorf("%s: HasMarkers() = %t, want %t", test.name, HasMarkers(merged), test.conflict)
+		}
+	}
+}
+
+func TestHasMarkers(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"", false},
+		{"plain text", false},
+		{"a\n=======\nb", false},
+		{"inline <<<<<<< ours marker", false},
+		{"<<<<<<< ours\na\n=======\nb", true},
+		{"a\n>>>>>>> theirs", true},
+	}
+	for _, test := range tests {
+		if got := HasMarkers(test.text); got != test.want {
+			t.Errorf("HasMarkers(%q) = %t, want %t", test.text, got, test.want)
+		}
+	}
+}
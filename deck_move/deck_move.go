@@ -0,0 +1,161 @@
+package deck_move
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"sort"
+	"tui-deck/deck_board"
+	"tui-deck/deck_card"
+	"tui-deck/deck_db"
+	"tui-deck/deck_help"
+	"tui-deck/deck_stack"
+	"tui-deck/deck_structs"
+	"tui-deck/deck_ui"
+	"tui-deck/utils"
+)
+
+// number of targets reachable with the number keys
+const quickTargets = 9
+
+var MoveList *tview.List
+var app *tview.Application
+var configuration utils.Configuration
+
+type target struct {
+	Board deck_structs.Board
+	Stack deck_structs.Stack
+}
+
+// move targets in list order, board headers and the current stack have no stack
+var targets []target
+var movingCard deck_structs.Card
+
+// stacks of the other boards by board id, missing while loading
+var boardStacks map[int][]deck_structs.Stack
+
+func Init(application *tview.Application, conf utils.Configuration) {
+	app = application
+	configuration = conf
+
+	MoveList = tview.NewList()
+	MoveList.SetBorder(true)
+	MoveList.SetBorderColor(utils.GetColor(configuration.Color))
+	MoveList.SetSelectedBackgroundColor(utils.GetColor(configuration.Color))
+	MoveList.ShowSecondaryText(false)
+
+	MoveList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			// ESC -> back to main view
+			deck_ui.BuildFullFlex(deck_ui.MainFlex, nil)
+			return nil
+		} else if event.Rune() == 63 {
+			// ? -> help
+			deck_ui.BuildHelp(MoveList, deck_help.HelpMove)
+			return nil
+		}
+		return event
+	})
+	// number keys are the shortcuts of the first targets
+	MoveList.SetSelectedFunc(func(index int, name string, secondName string, shortcut rune) {
+		if index >= len(targets) || targets[index].Stack.Id == 0 {
+			return
+		}
+		t := targets[index]
+		deck_ui.BuildFullFlex(deck_ui.MainFlex, nil)
+		deck_card.MoveCard(movingCard, t.Board, t.Stack)
+		if t.Board.Id != deck_board.CurrentBoard.Id {
+			// the stacks of the other board changed, get them again when switching to it
+			for i, b := range deck_board.Boards {
+				if b.Id == t.Board.Id {
+					deck_board.Boards[i].Updated = true
+					break
+				}
+			}
+		}
+	})
+}
+
+// BuildMove shows the stacks a card can be moved to, the ones of the current board first, then the other boards
+func BuildMove(card deck_structs.Card) {
+	movingCard = card
+	boardStacks = make(map[int][]deck_structs.Stack)
+	MoveList.SetTitle(fmt.Sprintf(" MOVE CARD #%d - %s ", card.Id, tview.Escape(card.Title)))
+	render()
+	MoveList.SetCurrentItem(1)
+	deck_ui.BuildFullFlex(MoveList, nil)
+
+	go func() {
+		for _, b := range otherBoards() {
+			stacks, err := deck_db.GetStacks(b.Id, b.Updated, configuration)
+			if err != nil {
+				stacks = make([]deck_structs.Stack, 0)
+			}
+			sort.Slice(stacks, func(i, j int) bool {
+				return stacks[i].Order < stacks[j].Order
+			})
+			boardId := b.Id
+			app.QueueUpdateDraw(func() {
+				if err != nil {
+					deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting stacks: %s", err.Error()))
+				}
+				boardStacks[boardId] = stacks
+				current := MoveList.GetCurrentItem()
+				render()
+				MoveList.SetCurrentItem(current)
+			})
+		}
+	}()
+}
+
+// otherBoards returns the boards, other than the current one, where cards can be moved to
+func otherBoards() []deck_structs.Board {
+	boards := make([]deck_structs.Board, 0)
+	for _, b := range deck_board.Boards {
+		if b.Id != deck_board.CurrentBoard.Id && !b.Archived && b.CanEdit() {
+			boards = append(boards, b)
+		}
+	}
+	return boards
+}
+
+func render() {
+	MoveList.Clear()
+	targets = make([]target, 0)
+	quick := 0
+	addHeader := func(board deck_structs.Board) {
+		MoveList.AddItem(fmt.Sprintf("[#%s::b]%s[-::-]", board.Color, tview.Escape(board.Title)), "", rune(0), nil)
+		targets = append(targets, target{Board: board})
+	}
+	addStack := func(board deck_structs.Board, stack deck_structs.Stack) {
+		if board.Id == deck_board.CurrentBoard.Id && stack.Id == movingCard.StackId {
+			MoveList.AddItem(fmt.Sprintf("  [gray]%s (current)[-]", tview.Escape(stack.Title)), "", rune(0), nil)
+			targets = append(targets, target{Board: board})
+			return
+		}
+		shortcut := rune(0)
+		if quick < quickTargets {
+			quick++
+			shortcut = rune('0' + quick)
+		}
+		MoveList.AddItem(fmt.Sprintf("  %s", tview.Escape(stack.Title)), "", shortcut, nil)
+		targets = append(targets, target{Board: board, Stack: stack})
+	}
+
+	addHeader(deck_board.CurrentBoard)
+	for _, s := range deck_stack.Stacks {
+		addStack(deck_board.CurrentBoard, s)
+	}
+	for _, b := range otherBoards() {
+		addHeader(b)
+		stacks, loaded := boardStacks[b.Id]
+		if !loaded {
+			MoveList.AddItem("  [gray]loading...[-]", "", rune(0), nil)
+			targets = append(targets, target{Board: b})
+			continue
+		}
+		for _, s := range stacks {
+			addStack(b, s)
+		}
+	}
+}
@@ -25,12 +25,11 @@ func Init(application *tview.Application, conf utils.Configuration) {
 	Modal = tview.NewModal()
 }
 func GetActualStack(actualList *tview.List) (int, deck_structs.Stack, error) {
-	for i, s := range Stacks {
-		if s.Title == strings.TrimSpace(actualList.GetTitle()) {
-			return i, s, nil
-		}
+	i, ok := deck_ui.Primitives[actualList]
+	if !ok || i >= len(Stacks) {
+		return 0, deck_structs.Stack{}, errors.New("not found")
 	}
-	return 0, deck_structs.Stack{}, errors.New("not found")
+	return i, Stacks[i], nil
 }
 
 func AddStack(boardId int, stack deck_structs.Stack) error {
@@ -1,6 +1,7 @@
 package deck_structs
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -20,17 +21,99 @@ func (owner *Owner) GetAbbrv() string {
 	return strings.ToUpper(fmt.Sprintf("%c", owner.DisplayName[0]))
 }
 
+// UnmarshalJSON accepts an owner object or, as returned by some endpoints, the bare uid
+func (owner *Owner) UnmarshalJSON(data []byte) error {
+	var uid string
+	if json.Unmarshal(data, &uid) == nil {
+		*owner = Owner{PrimaryKey: uid, Uid: uid, DisplayName: uid}
+		return nil
+	}
+	type plainOwner Owner
+	return json.Unmarshal(data, (*plainOwner)(owner))
+}
+
 type Board struct {
-	Id             int     `json:"id"`
-	Title          string  `json:"title"`
-	Owner          Owner   `json:"owner"`
-	Color          string  `json:"color"`
-	Labels         []Label `json:"labels"`
-	Etag           string  `json:"etag"`
-	Updated        bool    `json:"-"`
-	CreateDefaults bool    `json:"-"`
-	DeletedAt      int     `json:"deletedAt"`
-	Users          []Owner `json:"users"`
+	Id             int              `json:"id"`
+	Title          string           `json:"title"`
+	Owner          Owner            `json:"owner"`
+	Color          string           `json:"color"`
+	Labels         []Label          `json:"labels"`
+	Etag           string           `json:"etag"`
+	Updated        bool             `json:"-"`
+	CreateDefaults bool             `json:"-"`
+	DeletedAt      int              `json:"deletedAt"`
+	Users          []Owner          `json:"users"`
+	Archived       bool             `json:"archived"`
+	LastModified   int64            `json:"lastModified"`
+	Permissions    BoardPermissions `json:"permissions"`
+	Acl            []Acl            `json:"acl"`
+	Settings       BoardSettings    `json:"settings"`
+}
+
+// CanEdit reports if the cards of the board can be changed,
+// boards cached before the permissions were stored are considered editable
+func (board *Board) CanEdit() bool {
+	return !board.Permissions.Read || board.Permissions.Edit
+}
+
+// CanManage reports if the stacks, the labels and the settings of the board can be changed
+func (board *Board) CanManage() bool {
+	return !board.Permissions.Read || board.Permissions.Manage
+}
+
+// CanShare reports if the board can be shared with other users
+func (board *Board) CanShare() bool {
+	return !board.Permissions.Read || board.Permissions.Share
+}
+
+type BoardPermissions struct {
+	Read   bool `json:"PERMISSION_READ"`
+	Edit   bool `json:"PERMISSION_EDIT"`
+	Manage bool `json:"PERMISSION_MANAGE"`
+	Share  bool `json:"PERMISSION_SHARE"`
+}
+
+// participant types of acls and assigned users, the same as the share types of the sharee search
+const (
+	ParticipantUser   = 0
+	ParticipantGroup  = 1
+	ParticipantCircle = 7
+)
+
+// ParticipantTypeName returns the name of a participant type
+func ParticipantTypeName(participantType int) string {
+	switch participantType {
+	case ParticipantGroup:
+		return "group"
+	case ParticipantCircle:
+		return "circle"
+	}
+	return "user"
+}
+
+// Acl is a sharing of a board with a user (type 0), a group (type 1) or a circle (type 7)
+type Acl struct {
+	Id               int   `json:"id"`
+	BoardId          int   `json:"boardId"`
+	Type             int   `json:"type"`
+	Participant      Owner `json:"participant"`
+	PermissionEdit   bool  `json:"permissionEdit"`
+	PermissionShare  bool  `json:"permissionShare"`
+	PermissionManage bool  `json:"permissionManage"`
+	Owner            bool  `json:"owner"`
+}
+
+// BoardSettings are the per user settings of a board, like notify-due and calendar
+type BoardSettings map[string]interface{}
+
+// UnmarshalJSON accepts the empty list php encodes for a board without settings
+func (settings *BoardSettings) UnmarshalJSON(data []byte) error {
+	var list []interface{}
+	if json.Unmarshal(data, &list) == nil {
+		*settings = BoardSettings{}
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]interface{})(settings))
 }
 
 type Stack struct {
@@ -41,15 +124,41 @@ type Stack struct {
 }
 
 type Card struct {
-	Id            int            `json:"id"`
-	Title         string         `json:"title"`
-	Description   string         `json:"description"`
-	Labels        []Label        `json:"labels"`
-	StackId       int            `json:"stackId"`
-	Order         int            `json:"order"`
-	Type          string         `json:"type"`
-	DueDate       string         `json:"duedate"`
-	AssignedUsers []AssignedUser `json:"assignedUsers"`
+	Id              int            `json:"id"`
+	Title           string         `json:"title"`
+	Description     string         `json:"description"`
+	Labels          []Label        `json:"labels"`
+	StackId         int            `json:"stackId"`
+	Order           int            `json:"order"`
+	Type            string         `json:"type"`
+	DueDate         string         `json:"duedate"`
+	Done            string         `json:"done"`
+	AssignedUsers   []AssignedUser `json:"assignedUsers"`
+	Owner           Owner          `json:"owner"`
+	CreatedAt       int64          `json:"createdAt"`
+	LastModified    int64          `json:"lastModified"`
+	DeletedAt       int64          `json:"deletedAt"`
+	Archived        bool           `json:"archived"`
+	CommentsCount   int            `json:"commentsCount"`
+	CommentsUnread  int            `json:"commentsUnread"`
+	AttachmentCount int            `json:"attachmentCount"`
+	ETag            string         `json:"ETag"`
+}
+
+type Attachment struct {
+	Id           int            `json:"id"`
+	CardId       int            `json:"cardId"`
+	Type         string         `json:"type"`
+	Data         string         `json:"data"`
+	CreatedBy    string         `json:"createdBy"`
+	CreatedAt    int64          `json:"createdAt"`
+	LastModified int64          `json:"lastModified"`
+	ExtendedData AttachmentData `json:"extendedData"`
+}
+
+type AttachmentData struct {
+	Filesize int64  `json:"filesize"`
+	Mimetype string `json:"mimetype"`
 }
 
 type AssignedUser struct {
@@ -77,6 +186,14 @@ type OcsResponseUsers struct {
 	Ocs OcsUsers `json:"ocs"`
 }
 
+type OcsResponseActivities struct {
+	Ocs OcsActivities `json:"ocs"`
+}
+
+type OcsResponseSharees struct {
+	Ocs OcsSharees `json:"ocs"`
+}
+
 type Ocs struct {
 	Meta Meta      `json:"meta"`
 	Data []Comment `json:"data"`
@@ -86,6 +203,50 @@ type OcsSingle struct {
 	Data Comment `json:"data"`
 }
 
+type OcsActivities struct {
+	Meta Meta       `json:"meta"`
+	Data []Activity `json:"data"`
+}
+
+type OcsSharees struct {
+	Meta Meta    `json:"meta"`
+	Data Sharees `json:"data"`
+}
+
+// Sharees are the results of a sharee search, exact matches are listed apart
+type Sharees struct {
+	Exact   json.RawMessage `json:"exact"`
+	Users   []Sharee        `json:"users"`
+	Groups  []Sharee        `json:"groups"`
+	Circles []Sharee        `json:"circles"`
+}
+
+type Sharee struct {
+	Label string      `json:"label"`
+	Value ShareeValue `json:"value"`
+}
+
+// ShareeValue identifies a user (share type 0), a group (1) or a circle (7), the same types of an Acl
+type ShareeValue struct {
+	ShareType int    `json:"shareType"`
+	ShareWith string `json:"shareWith"`
+}
+
+type OcsResponseCircleMembers struct {
+	Ocs OcsCircleMembers `json:"ocs"`
+}
+
+type OcsCircleMembers struct {
+	Meta Meta           `json:"meta"`
+	Data []CircleMember `json:"data"`
+}
+
+type CircleMember struct {
+	UserId      string `json:"userId"`
+	UserType    int    `json:"userType"`
+	DisplayName string `json:"displayName"`
+}
+
 type OcsUsers struct {
 	Meta Meta  `json:"meta"`
 	Data Users `json:"data"`
@@ -114,7 +275,27 @@ type Comment struct {
 }
 
 type Mention struct {
-	MentionId          int    `json:"mentionId"`
+	MentionId          string `json:"mentionId"`
 	MentionType        string `json:"mentionType"`
 	MentionDisplayName string `json:"mentionDisplayName"`
 }
+
+type Activity struct {
+	ActivityId int    `json:"activity_id"`
+	App        string `json:"app"`
+	Type       string `json:"type"`
+	User       string `json:"user"`
+	Subject    string `json:"subject"`
+	// subject with {placeholders} followed by the object describing each placeholder
+	SubjectRich []json.RawMessage `json:"subject_rich"`
+	Message     string            `json:"message"`
+	Datetime    string            `json:"datetime"`
+	ObjectType  string            `json:"object_type"`
+	ObjectId    int               `json:"object_id"`
+}
+
+type RichObject struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
@@ -1,8 +1,12 @@
 package deck_ui
 
 import (
+	"errors"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"os"
+	"os/exec"
+	"strings"
 	"tui-deck/deck_help"
 	"tui-deck/utils"
 )
@@ -92,6 +96,22 @@ func BuildHelp(primitive tview.Primitive, helpView *tview.TextView) {
 				help.SetPrimitive(deck_help.HelpBoards)
 				return nil
 			case help.GetPrimitive() == deck_help.HelpBoards:
+				help.SetTitle(deck_help.HelpCalendar.GetTitle())
+				help.SetPrimitive(deck_help.HelpCalendar)
+				return nil
+			case help.GetPrimitive() == deck_help.HelpCalendar:
+				help.SetTitle(deck_help.HelpArchive.GetTitle())
+				help.SetPrimitive(deck_help.HelpArchive)
+				return nil
+			case help.GetPrimitive() == deck_help.HelpArchive:
+				help.SetTitle(deck_help.HelpAcl.GetTitle())
+				help.SetPrimitive(deck_help.HelpAcl)
+				return nil
+			case help.GetPrimitive() == deck_help.HelpAcl:
+				help.SetTitle(deck_help.HelpMove.GetTitle())
+				help.SetPrimitive(deck_help.HelpMove)
+				return nil
+			case help.GetPrimitive() == deck_help.HelpMove:
 				help.SetTitle(deck_help.HelpMain.GetTitle())
 				help.SetPrimitive(deck_help.HelpMain)
 				return nil
@@ -107,3 +127,53 @@ func GetNextFocus(index int) tview.Primitive {
 	}
 	return PrimitivesIndexMap[index]
 }
+
+// OpenEditor suspends the application and lets the user edit text in $VISUAL or $EDITOR,
+// changed reports if the content has been modified
+func OpenEditor(text string) (string, bool, error) {
+	editor := os.Getenv("VISUAL")
+	if len(editor) == 0 {
+		editor = os.Getenv("EDITOR")
+	}
+	if len(editor) == 0 {
+		editor = "vi"
+	}
+
+	file, err := os.CreateTemp("", "tui-deck-*.md")
+	if err != nil {
+		return text, false, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(text)
+	if err != nil {
+		return text, false, err
+	}
+	err = file.Close()
+	if err != nil {
+		return text, false, err
+	}
+
+	args := strings.Fields(editor)
+	var runErr error
+	suspended := app.Suspend(func() {
+		cmd := exec.Command(args[0], append(args[1:], file.Name())...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		runErr = cmd.Run()
+	})
+	if !suspended {
+		return text, false, errors.New("unable to suspend the application")
+	}
+	if runErr != nil {
+		return text, false, runErr
+	}
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return text, false, err
+	}
+	// editors usually append a final newline
+	edited := strings.TrimRight(string(content), "\n")
+	return edited, edited != strings.TrimRight(text, "\n"), nil
+}
@@ -3,11 +3,14 @@ module tui-deck
 go 1.20
 
 require (
+	github.com/alecthomas/chroma/v2 v2.14.0
 	github.com/gdamore/tcell/v2 v2.6.0
 	github.com/rivo/tview v0.0.0-20230525073430-4a1f85bb2219
+	github.com/yuin/goldmark v1.7.8
 )
 
 require (
+	github.com/dlclark/regexp2 v1.11.0 // indirect
 	github.com/emersion/go-ical v0.0.0-20220601085725-0864dccc089f // indirect
 	github.com/emersion/go-webdav v0.4.0 // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
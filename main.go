@@ -6,13 +6,21 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"os"
-	"time"
+	"tui-deck/deck_acl"
+	"tui-deck/deck_activity"
+	"tui-deck/deck_archive"
+	"tui-deck/deck_attachment"
 	"tui-deck/deck_board"
+	"tui-deck/deck_calendar"
 	"tui-deck/deck_card"
 	"tui-deck/deck_comment"
+	"tui-deck/deck_date"
 	"tui-deck/deck_db"
+	"tui-deck/deck_export"
 	"tui-deck/deck_help"
 	"tui-deck/deck_http"
+	"tui-deck/deck_link"
+	"tui-deck/deck_move"
 	"tui-deck/deck_stack"
 	"tui-deck/deck_structs"
 	"tui-deck/deck_ui"
@@ -37,8 +45,24 @@ func main() {
 		deck_ui.FooterBar.SetText(err.Error())
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err = deck_date.Init(configuration); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		err = deck_export.Run(os.Args[2:], configuration)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Print("Getting boards...\n")
 	deck_ui.Init(app, configuration)
+	if err = deck_date.Init(configuration); err != nil {
+		deck_ui.FooterBar.SetText(err.Error())
+	}
+	deck_link.Init(configuration)
 	deck_board.Init(app, configuration)
 	var fatalError = false
 	deck_board.Boards, err = deck_http.GetBoards(configuration)
@@ -77,12 +101,19 @@ func main() {
 		} else {
 			deck_ui.FooterBar.SetText("No boards found")
 		}
-		deck_ui.MainFlex.SetTitle(fmt.Sprintf(" TUI DECK: [#%s]%s ", deck_board.CurrentBoard.Color, deck_board.CurrentBoard.Title))
+		deck_ui.MainFlex.SetTitle(deck_board.GetMainTitle(deck_board.CurrentBoard))
 
 		fmt.Print("Getting stacks...\n")
 		deck_stack.Init(app, configuration)
 		deck_card.Init(app, configuration, deck_board.CurrentBoard)
+		deck_card.SwitchBoard = deck_board.SelectBoard
 		deck_comment.Init(app, configuration)
+		deck_activity.Init(app, configuration)
+		deck_attachment.Init(app, configuration)
+		deck_archive.Init(app, configuration)
+		deck_acl.Init(app, configuration)
+		deck_move.Init(app, configuration)
+		deck_calendar.Init(app, configuration)
 		deck_stack.Stacks, err = deck_db.GetStacks(deck_board.CurrentBoard.Id, deck_board.CurrentBoard.Updated, configuration)
 		if err != nil {
 			deck_ui.FooterBar.SetText(fmt.Sprintf("Error getting stacks: %s", err.Error()))
@@ -115,40 +146,46 @@ func main() {
 				deck_ui.BuildFullFlex(deck_board.BoardFlex, nil)
 			} else if event.Rune() == 97 {
 				// a -> add card
-				if len(deck_stack.Stacks) == 0 {
+				if len(deck_stack.Stacks) == 0 || !deck_card.CanEdit("add cards") {
 					return nil
 				}
 				actualList := app.GetFocus().(*tview.List)
 				addForm, card := deck_card.BuildAddForm()
 				addForm.AddButton("Save", func() {
-					if len(card.DueDate) > 0 {
-
-						dueDate := card.DueDate
-						pattern := "02/01/2006 15:04"
-						parse, err2 := time.Parse(pattern, dueDate)
-						if err2 != nil {
-							deck_ui.FooterBar.SetText(fmt.Sprintf("Not a valid date, format must be dd/MM/YYYY HH:mm: %s", err2.Error()))
-							return
-						}
-						card.DueDate = parse.Format("2006-01-02T15:04:05+00:00")
+					dueDate, err2 := deck_date.InputToApi(card.DueDate)
+					if err2 != nil {
+						deck_ui.FooterBar.SetText(fmt.Sprintf("Not a valid date: %s", err2.Error()))
+						return
 					}
+					card.DueDate = dueDate
 					deck_card.AddCard(actualList, *card)
 				})
 				deck_ui.BuildFullFlex(addForm, nil)
 			} else if event.Rune() == 100 {
-				if len(deck_stack.Stacks) == 0 {
+				if len(deck_stack.Stacks) == 0 || !deck_card.CanEdit("delete cards") {
 					return nil
 				}
-				// d -> delete card
+				// d -> delete card, or archive it with preferArchive
 				actualList := app.GetFocus().(*tview.List)
+				if actualList.GetItemCount() == 0 {
+					return nil
+				}
 				var _, stack, _ = deck_stack.GetActualStack(actualList)
 				var currentItemIndex = actualList.GetCurrentItem()
 				mainText, _ := actualList.GetItemText(currentItemIndex)
 				cardId := utils.GetId(mainText)
+				if configuration.PreferArchive {
+					deck_card.ArchiveCard(deck_card.CardsMap[cardId], true, nil)
+					deck_card.FocusCard(stack.Id, cardId, currentItemIndex)
+					return nil
+				}
 				deck_card.DeleteCard(cardId, stack, actualList, currentItemIndex)
 
 			} else if event.Key() == tcell.KeyCtrlA {
 				// ctrl + a -> add stack
+				if !deck_card.CanManage("add stacks") {
+					return nil
+				}
 				addForm, stack := deck_stack.BuildAddForm(deck_structs.Stack{})
 				addForm.AddButton("Save", func() {
 					err := deck_stack.AddStack(deck_board.CurrentBoard.Id, *stack)
@@ -159,7 +196,7 @@ func main() {
 
 			} else if event.Key() == tcell.KeyCtrlD {
 				// ctrl + d -> delete stack
-				if len(deck_stack.Stacks) == 0 {
+				if len(deck_stack.Stacks) == 0 || !deck_card.CanManage("delete stacks") {
 					return nil
 				}
 
@@ -189,6 +226,9 @@ func main() {
 
 			} else if event.Key() == tcell.KeyCtrlE {
 				// ctrl + e -> edit stack
+				if len(deck_stack.Stacks) == 0 || !deck_card.CanManage("edit stacks") {
+					return nil
+				}
 				actualList := app.GetFocus().(*tview.List)
 
 				index := deck_ui.Primitives[app.GetFocus()]
@@ -207,6 +247,78 @@ func main() {
 				})
 				deck_ui.BuildFullFlex(editForm, nil)
 
+			} else if event.Rune() == 89 {
+				// Y -> copy card web url
+				if len(deck_stack.Stacks) == 0 {
+					return nil
+				}
+				actualList := app.GetFocus().(*tview.List)
+				if actualList.GetItemCount() == 0 {
+					return nil
+				}
+				mainText, _ := actualList.GetItemText(actualList.GetCurrentItem())
+				deck_card.CopyCardUrl(utils.GetId(mainText))
+				return nil
+			} else if event.Rune() == 120 {
+				// x -> archive card
+				if len(deck_stack.Stacks) == 0 || !deck_card.CanEdit("archive cards") {
+					return nil
+				}
+				actualList := app.GetFocus().(*tview.List)
+				if actualList.GetItemCount() == 0 {
+					return nil
+				}
+				index := actualList.GetCurrentItem()
+				mainText, _ := actualList.GetItemText(index)
+				card := deck_card.CardsMap[utils.GetId(mainText)]
+				deck_card.ArchiveCard(card, true, nil)
+				deck_card.FocusCard(card.StackId, card.Id, index)
+				return nil
+			} else if event.Rune() == 68 {
+				// D -> mark card done / not done
+				if len(deck_stack.Stacks) == 0 || !deck_card.CanEdit("mark cards done") {
+					return nil
+				}
+				actualList := app.GetFocus().(*tview.List)
+				if actualList.GetItemCount() == 0 {
+					return nil
+				}
+				index := actualList.GetCurrentItem()
+				mainText, _ := actualList.GetItemText(index)
+				card := deck_card.CardsMap[utils.GetId(mainText)]
+				deck_card.SetCardDone(card, len(card.Done) == 0)
+				deck_card.FocusCard(card.StackId, card.Id, index)
+				return nil
+			} else if event.Rune() == 109 {
+				// m -> move card to any stack or board
+				if len(deck_stack.Stacks) == 0 || !deck_card.CanEdit("move cards") {
+					return nil
+				}
+				actualList := app.GetFocus().(*tview.List)
+				if actualList.GetItemCount() == 0 {
+					return nil
+				}
+				mainText, _ := actualList.GetItemText(actualList.GetCurrentItem())
+				deck_move.BuildMove(deck_card.CardsMap[utils.GetId(mainText)])
+				return nil
+			} else if event.Rune() == 72 {
+				// H -> hide / show done cards
+				deck_card.HideDone = !deck_card.HideDone
+				deck_card.BuildStacks()
+				if deck_card.HideDone {
+					deck_ui.FooterBar.SetText("Done cards hidden, press [yellow]H[white] to show them")
+				} else {
+					deck_ui.FooterBar.SetText("Done cards shown")
+				}
+				return nil
+			} else if event.Rune() == 88 {
+				// X -> archived cards
+				deck_archive.BuildArchive(deck_board.CurrentBoard)
+				return nil
+			} else if event.Rune() == 99 {
+				// c -> calendar
+				deck_calendar.BuildCalendar()
+				return nil
 			} else if event.Rune() == 63 {
 				// ? deck_help menu
 				deck_ui.BuildHelp(deck_ui.MainFlex, deck_help.HelpMain)
@@ -12,11 +12,17 @@ import (
 )
 
 type Configuration struct {
-	User      string `json:"username"`
-	Password  string `json:"password"`
-	Url       string `json:"url"`
-	Color     string `json:"color"`
-	ConfigDir string
+	User          string `json:"username"`
+	Password      string `json:"password"`
+	Url           string `json:"url"`
+	Color         string `json:"color"`
+	DateFormat    string `json:"dateFormat"`
+	DueSoonDays   int    `json:"dueSoonDays"`
+	CodeStyle     string `json:"codeStyle"`
+	OpenCommand   string `json:"openCommand"`
+	PreferArchive bool   `json:"preferArchive"`
+	DoneStack     string `json:"doneStack"`
+	ConfigDir     string
 }
 
 func InitConfingDirectory() (string, error) {
@@ -32,11 +38,13 @@ func InitConfingDirectory() (string, error) {
 		create, err := os.Create(configFile)
 
 		configuration := Configuration{
-			User:      "",
-			Password:  "",
-			Url:       "https://nextcloud.example.com",
-			Color:     "#BF40BF",
-			ConfigDir: configDir,
+			User:        "",
+			Password:    "",
+			Url:         "https://nextcloud.example.com",
+			Color:       "#BF40BF",
+			DateFormat:  "dd/MM/yyyy HH:mm",
+			DueSoonDays: 3,
+			ConfigDir:   configDir,
 		}
 		jsonConfig, err := json.Marshal(configuration)
 		if err != nil {
@@ -147,3 +155,8 @@ func CommaString(a []string) string {
 	}
 	return res
 }
+
+// GetCardUrl returns the link to a card in the deck web ui
+func GetCardUrl(configuration Configuration, boardId int, cardId int) string {
+	return fmt.Sprintf("%s/index.php/apps/deck/board/%d/card/%d", strings.TrimSuffix(configuration.Url, "/"), boardId, cardId)
+}